@@ -0,0 +1,70 @@
+package sqldb
+
+import (
+	"github.com/samber/lo"
+)
+
+// FilterDescriptor describes a filter option in a neutral and serializable form, e.g. for audit logging.
+type FilterDescriptor struct {
+	Type FilterOptionType `json:"type"`
+	// Column is the full name of the filtered column, it is empty for groups.
+	Column string `json:"column,omitempty"`
+	// Op is the query operator, "IN" or "NOT IN" for range queries, "LIKE" for fuzzy queries and the logical operator for groups.
+	Op string `json:"op"`
+	// Value is the value compared by an op query.
+	Value any `json:"value,omitempty"`
+	// Values are the values of a range query or the patterns of a fuzzy query.
+	Values []any `json:"values,omitempty"`
+	// RightColumn is the full name of the column compared by a join condition.
+	RightColumn   string `json:"right_column,omitempty"`
+	CaseSensitive bool   `json:"case_sensitive,omitempty"`
+	// Filters are the members of a group.
+	Filters []FilterDescriptor `json:"filters,omitempty"`
+}
+
+// DescribeFilters describes the filter options in the order they are applied, values are not serialized by columns.
+func DescribeFilters(opts []FilterOption) []FilterDescriptor {
+	filterOpts := parseFilterOptions(opts)
+	descriptors := make([]FilterDescriptor, 0, len(opts))
+	for _, opt := range filterOpts.opJoinOptions {
+		descriptors = append(descriptors, FilterDescriptor{
+			Type:        FilterOptionTypeOpQuery,
+			Column:      opt.GetLeftColumnName().Full(),
+			Op:          string(opt.QueryOp()),
+			RightColumn: opt.GetRightColumnName().Full(),
+		})
+	}
+	for _, opt := range filterOpts.opQueryOptions {
+		descriptors = append(descriptors, FilterDescriptor{
+			Type:   FilterOptionTypeOpQuery,
+			Column: opt.GetColumnName().Full(),
+			Op:     string(opt.QueryOp()),
+			Value:  opt.GetValue(),
+		})
+	}
+	for _, opt := range filterOpts.rangeQueryOptions {
+		descriptors = append(descriptors, FilterDescriptor{
+			Type:   FilterOptionTypeRangeQuery,
+			Column: opt.GetColumnName().Full(),
+			Op:     lo.Ternary(opt.Exclude(), "NOT IN", "IN"),
+			Values: opt.GetValues(),
+		})
+	}
+	for _, opt := range filterOpts.fuzzyQueryOptions {
+		descriptors = append(descriptors, FilterDescriptor{
+			Type:          FilterOptionTypeFuzzyQuery,
+			Column:        opt.GetColumnName().Full(),
+			Op:            "LIKE",
+			Values:        opt.GetValues(),
+			CaseSensitive: opt.CaseSensitive(),
+		})
+	}
+	for _, opt := range filterOpts.groupOptions {
+		descriptors = append(descriptors, FilterDescriptor{
+			Type:    FilterOptionTypeGroup,
+			Op:      string(opt.GroupOp()),
+			Filters: DescribeFilters(opt.GetFilterOptions()),
+		})
+	}
+	return descriptors
+}
@@ -0,0 +1,107 @@
+package sqldb
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// ExportFormat is the format of the data written by Executor.Export.
+type ExportFormat string
+
+const (
+	// ExportFormatNDJSON writes each entity as a JSON object in a separate line.
+	ExportFormatNDJSON ExportFormat = "ndjson"
+	// ExportFormatCSV writes a header line of column names followed by a line for each entity, only the columns of
+	// ListOptions.Columns are written if it is not empty.
+	ExportFormatCSV ExportFormat = "csv"
+)
+
+func (e executor[T]) Export(ctx context.Context, opts ListOptions, w io.Writer, format ExportFormat) (err error) {
+	defer func() { err = e.mapError(err) }()
+	switch format {
+	case ExportFormatNDJSON:
+		return e.iterate(ctx, opts, func(entity T) error {
+			raw, err := e.Marshal(entity)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(append(raw, '\n'))
+			return err
+		})
+	case ExportFormatCSV:
+		writer := csv.NewWriter(w)
+		// the header is written before the entities, so that an empty result still has it.
+		header, _, err := e.csvRecord(new(T), opts.Columns)
+		if err != nil {
+			return err
+		}
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+		if err := e.iterate(ctx, opts, func(entity T) error {
+			_, record, err := e.csvRecord(&entity, opts.Columns)
+			if err != nil {
+				return err
+			}
+			return writer.Write(record)
+		}); err != nil {
+			return err
+		}
+		writer.Flush()
+		return writer.Error()
+	default:
+		return fmt.Errorf("unsupported export format %s", format)
+	}
+}
+
+// csvRecord returns the header and the record of entity with the selected columns in order, or all the columns if
+// selected is empty. Aliased columns are named by their aliases in the header.
+func (e executor[T]) csvRecord(entity *T, selected []ColumnNameGetter) (header, record []string, err error) {
+	values := map[string]string{}
+	if err := e.iterateColumns(entity, func(column ColumnNameGetter, fieldAddr reflect.Value, _ reflect.StructField) error {
+		var v string
+		if fieldAddr.IsValid() {
+			var err error
+			if v, err = csvValue(fieldAddr.Interface()); err != nil {
+				return fmt.Errorf("failed to export the column %s: %w", column.GetColumnName(), err)
+			}
+		}
+		if len(selected) == 0 {
+			header = append(header, column.GetColumnName().String())
+			record = append(record, v)
+		}
+		values[column.GetColumnName().Full()] = v
+		return nil
+	}); err != nil {
+		return nil, nil, err
+	}
+	for _, col := range selected {
+		v, exist := values[col.GetColumnName().Full()]
+		if !exist {
+			return nil, nil, fmt.Errorf("the selected column %s is not a column of the model", col.GetColumnName())
+		}
+		header = append(header, selectAlias(col, col.GetColumnName().String()))
+		record = append(record, v)
+	}
+	return header, record, nil
+}
+
+// csvValue formats the value of a column as a CSV field, strings are written without quotes and NULL is written as an empty field.
+func csvValue(column any) (string, error) {
+	raw, err := json.Marshal(column)
+	if err != nil {
+		return "", err
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s, nil
+	}
+	if string(raw) == "null" {
+		return "", nil
+	}
+	return string(raw), nil
+}
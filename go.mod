@@ -5,6 +5,7 @@ go 1.18
 require (
 	github.com/samber/lo v1.33.0
 	github.com/samber/mo v1.7.0
+	github.com/shopspring/decimal v1.3.1 // only imported by the tests of decimal columns
 	github.com/stretchr/testify v1.8.0
 	gorm.io/driver/sqlite v1.4.2
 	gorm.io/gorm v1.24.0
@@ -16,6 +17,7 @@ require (
 	github.com/jinzhu/now v1.1.5 // indirect
 	github.com/mattn/go-sqlite3 v1.14.15 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/stretchr/objx v0.4.0 // indirect
 	golang.org/x/exp v0.0.0-20220303212507-bbda1eaf7a17 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,35 @@
+package sqldb
+
+import (
+	"context"
+
+	"github.com/samber/lo"
+)
+
+// GetByIDs returns the entities whose id column is in ids keyed by their ids, id returns the id column of an entity,
+// for example:
+//
+//	users, err := sqldb.GetByIDs(ctx, m, func(u *User) *sqldb.Column[uint64] { return &u.ID }, []uint64{3, 1})
+//
+// Entities that do not exist are absent from the map.
+func GetByIDs[T any, K comparable](ctx context.Context, m Model[T], id func(*T) *Column[K], ids []K) (map[K]T, error) {
+	if len(ids) == 0 {
+		return map[K]T{}, nil
+	}
+	columns := m.Columns()
+	entities, err := m.Query(id(&columns).In(lo.Uniq(ids))).Find(ctx, ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return lo.KeyBy(entities, func(entity T) K { return id(&entity).V }), nil
+}
+
+// GetByIDsOrdered is like GetByIDs but returns the entities in a slice aligned to ids, as dataloaders expect.
+// The element of an id that does not exist is the zero value of T, whose id column is the zero value of K.
+func GetByIDsOrdered[T any, K comparable](ctx context.Context, m Model[T], id func(*T) *Column[K], ids []K) ([]T, error) {
+	entities, err := GetByIDs(ctx, m, id, ids)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(ids, func(k K, _ int) T { return entities[k] }), nil
+}
@@ -2,14 +2,21 @@ package sqldb
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
 
 	"github.com/samber/lo"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// Tabler is implemented by types which know the name of its table, Model is a Tabler.
+type Tabler interface {
+	Table() string
+}
+
 type joinResultInterface interface {
 	_tableName() string
 	_left() any
@@ -36,28 +43,297 @@ func (r JoinedEntity[L, R]) _right() any {
 	return r.Right
 }
 
+// LeftJoin is like Join but keeps the entities of left without matching entities of right.
 func LeftJoin[L, R any](ctx context.Context, left Model[L], right Model[R], opts JoinOptions) Model[JoinedEntity[L, R]] {
-	return join(ctx, left, right, opts.SelectedColumns, opts.Conditions, true)
+	return join(ctx, left, right, opts, true)
 }
 
+// Join returns a model of the entities of left joined with the ones of right. Operations of the returned model start
+// from the db of left for the context passed to them, ctx is only used to build the model.
 func Join[L, R any](ctx context.Context, left Model[L], right Model[R], opts JoinOptions) Model[JoinedEntity[L, R]] {
-	return join(ctx, left, right, opts.SelectedColumns, opts.Conditions, false)
+	return join(ctx, left, right, opts, false)
 }
 
-func join[L, R any](ctx context.Context, left Model[L], right Model[R],
-	selectedColumns []ColumnNameGetter, conditions []OpOption, leftJoin bool) Model[JoinedEntity[L, R]] {
+func join[L, R any](ctx context.Context, left Model[L], right Model[R], opts JoinOptions, leftJoin bool) Model[JoinedEntity[L, R]] {
 	initial := func(db *gorm.DB) *gorm.DB {
-		conditions := lo.Map(conditions, func(opt OpOption, _ int) OpJoinOption { return opt.MustLeft() })
-		query := strings.Join(lo.Map(conditions, func(opt OpJoinOption, _ int) string {
-			return fmt.Sprintf("%s %s %s", opt.GetLeftColumnName().Full(), opt.QueryOp(), opt.GetRightColumnName().Full())
-		}), " AND ")
-		join := fmt.Sprintf("%s %s on %s", lo.Ternary(leftJoin, "LEFT JOIN", "INNER JOIN"), right.Table(), query)
-		return db.Model(new(L)).
-			Select(strings.Join(lo.Map(selectedColumns, func(getter ColumnNameGetter, _ int) string {
-				col := getter.GetColumnName()
-				return fmt.Sprintf("%s AS `%s`", col.Full(), col.Full())
-			}), ",")).
-			Joins(join)
-	}
-	return NewModel[JoinedEntity[L, R]](left.DB(ctx), WithDBInitialFunc(initial))
+		var (
+			conditions []string
+			vars       []any
+		)
+		if len(opts.Conditions) != 0 {
+			conditions = append(conditions, joinConditions(db, opts.Conditions).SQL)
+		}
+		for _, raw := range opts.RawConditions {
+			conditions, vars = append(conditions, "("+raw.SQL+")"), append(vars, raw.Vars...)
+		}
+		join := fmt.Sprintf("%s %s on %s", lo.Ternary(leftJoin, "LEFT JOIN", "INNER JOIN"), tableExpr(right), strings.Join(conditions, " AND "))
+		db = db.Model(new(L))
+		if expr := tableExpr(left); expr != left.Table() {
+			db = db.Table(expr)
+		}
+		selects, selectVars := joinedSelects(db, opts.SelectedColumns)
+		return db.Select(selects, selectVars...).Joins(join, vars...)
+	}
+	return NewModel[JoinedEntity[L, R]](left.DB(ctx), WithDBInitialFunc(initial), func(c *modelConfig) {
+		c.joinedTables = []string{tableRef(left), tableRef(right)}
+		c.baseDB = left.DB
+		c.filters = opts.Filters
+		c.selectAliases = selectAliases(opts.SelectedColumns)
+	})
+}
+
+// joinedSelects returns the select list of the columns of a joined model and its vars.
+func joinedSelects(db *gorm.DB, cols []ColumnNameGetter) (string, []any) {
+	var vars []any
+	return strings.Join(lo.Map(cols, func(getter ColumnNameGetter, _ int) string {
+		// columns are aliased with their full names, which are the keys used to scan joined entities,
+		// so that columns with the same name in both tables do not overwrite each other.
+		col := getter.GetColumnName()
+		expr, exprVars := selectExpr(getter, col.Full())
+		vars = append(vars, exprVars...)
+		return fmt.Sprintf("%s AS %s", expr, quoteAlias(db, selectAlias(getter, col.Full())))
+	}), ","), vars
+}
+
+// selectList returns the select list of db and its vars, which is * if nothing is selected.
+func selectList(db *gorm.DB) (string, []any) {
+	if c, ok := db.Statement.Clauses["SELECT"]; ok {
+		if expr, ok := c.Expression.(clause.Expr); ok {
+			return expr.SQL, expr.Vars
+		}
+	}
+	return lo.Ternary(len(db.Statement.Selects) == 0, "*", strings.Join(db.Statement.Selects, ",")), nil
+}
+
+// aliasedColumn is a column selected with an alias.
+type aliasedColumn struct {
+	ColumnNameGetter
+	alias string
+}
+
+// selectAlias returns the alias of column set by As, or def if there is none.
+func selectAlias(getter ColumnNameGetter, def string) string {
+	switch c := getter.(type) {
+	case aliasedColumn:
+		return c.alias
+	case coalescedColumn:
+		return selectAlias(c.ColumnNameGetter, def)
+	}
+	return def
+}
+
+// selectAliases returns the aliases of the aliased columns of cols keyed by their full names.
+func selectAliases(cols []ColumnNameGetter) map[string]string {
+	aliases := map[string]string{}
+	for _, col := range cols {
+		if alias := selectAlias(col, ""); alias != "" {
+			aliases[col.GetColumnName().Full()] = alias
+		}
+	}
+	return aliases
+}
+
+// coalescedColumn is a column selected as COALESCE(column, def).
+type coalescedColumn struct {
+	ColumnNameGetter
+	def any
+}
+
+// Coalesce wraps col so that it is selected as COALESCE(col, def) by SelectedColumns of joins and ListOptions.Columns,
+// e.g. columns of the right table of a left join are scanned as def instead of zero values when there is no match.
+// def is bound as a parameter of the select list, it must be a number, a bool or a string, otherwise Coalesce panics.
+// Filters and sorts on the returned column refer to col itself.
+func Coalesce(col ColumnNameGetter, def any) ColumnNameGetter {
+	switch def.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, bool, string:
+	default:
+		panic(fmt.Sprintf("unsupported default value %v of type %T for COALESCE", def, def))
+	}
+	return coalescedColumn{ColumnNameGetter: col, def: def}
+}
+
+// selectExpr returns the expression which selects column and its vars, coalesced columns are wrapped with COALESCE.
+func selectExpr(getter ColumnNameGetter, column string) (string, []any) {
+	if c, ok := getter.(coalescedColumn); ok {
+		return fmt.Sprintf("COALESCE(%s, ?)", column), []any{c.def}
+	}
+	return column, nil
+}
+
+// tableAliaser is implemented by models which may refer to their tables by aliases.
+type tableAliaser interface {
+	tableRef() string
+	tableExpr() string
+}
+
+// tableRef returns the name which columns of the table of t are qualified with.
+func tableRef(t Tabler) string {
+	if aliased, ok := t.(tableAliaser); ok {
+		return aliased.tableRef()
+	}
+	return t.Table()
+}
+
+// tableExpr returns the expression which refers to the table of t in FROM and JOIN clauses.
+func tableExpr(t Tabler) string {
+	if aliased, ok := t.(tableAliaser); ok {
+		return aliased.tableExpr()
+	}
+	return t.Table()
+}
+
+// quoteAlias quotes the alias which contains a dot as a single identifier.
+func quoteAlias(db *gorm.DB, alias string) string {
+	if db.Dialector.Name() == "postgres" {
+		return `"` + alias + `"`
+	}
+	return "`" + alias + "`"
+}
+
+func (e executor[T]) UpdateFrom(ctx context.Context, other Tabler, on []OpOption, sets ...UpdateOption) (_ uint64, err error) {
+	defer func() { err = e.mapError(err) }()
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+	if e.joined {
+		return 0, errors.New("UpdateFrom is not supported by joined models")
+	}
+	if len(sets) == 0 {
+		return 0, errors.New("empty options")
+	}
+	if len(on) == 0 {
+		return 0, errors.New("empty join conditions")
+	}
+	if err := e.checkWritable(lo.Map(sets, func(opt UpdateOption, _ int) ColumnNameGetter { return opt })); err != nil {
+		return 0, err
+	}
+	db, err := e.destructiveDB(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	mysql := db.Dialector.Name() == "mysql"
+	// MySQL requires qualified column names in SET when tables are joined, while Postgres and SQLite reject them.
+	setColumn := func(name string) clause.Column {
+		return clause.Column{Table: lo.Ternary(mysql, e.tableRef(), ""), Name: name}
+	}
+	set := make(clause.Set, 0, len(sets)+1)
+	for _, opt := range sets {
+		v, err := e.updateValue(ctx, opt, true)
+		if err != nil {
+			return 0, err
+		}
+		set = append(set, clause.Assignment{Column: setColumn(opt.GetColumnName().String()), Value: v})
+	}
+	if user := AuditUserFrom(ctx); user != nil && e.config.updatedBy != "" {
+		if _, exist := lo.Find(sets, func(opt UpdateOption) bool { return opt.GetColumnName().String() == e.config.updatedBy }); !exist {
+			set = append(set, clause.Assignment{Column: setColumn(e.config.updatedBy), Value: user})
+		}
+	}
+	where, err := e.whereClause(ctx, db, e.filters())
+	if err != nil {
+		return 0, err
+	}
+	notDeleted, err := e.notDeletedAt(db)
+	if err != nil {
+		return 0, err
+	}
+	where.Exprs = append(where.Exprs, notDeleted...)
+	var (
+		sql  strings.Builder
+		vars []any
+	)
+	if mysql {
+		sql.WriteString(fmt.Sprintf("UPDATE %s JOIN %s ON ? SET ?", e.tableExpr(), tableExpr(other)))
+		vars = append(vars, joinConditions(db, on), set)
+	} else {
+		sql.WriteString(fmt.Sprintf("UPDATE %s SET ? FROM %s", e.tableExpr(), tableExpr(other)))
+		vars = append(vars, set)
+		where.Exprs = append([]clause.Expression{joinConditions(db, on)}, where.Exprs...)
+	}
+	if len(where.Exprs) != 0 {
+		sql.WriteString(" WHERE ?")
+		vars = append(vars, where)
+	}
+	updated := db.Exec(sql.String(), vars...)
+	return uint64(updated.RowsAffected), updated.Error
+}
+
+// notDeletedAt returns the conditions which skip the rows soft deleted by gorm.DeletedAt unless db is unscoped,
+// gorm only adds them to the statements it builds itself.
+func (e executor[T]) notDeletedAt(db *gorm.DB) ([]clause.Expression, error) {
+	if db.Statement.Unscoped {
+		return nil, nil
+	}
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	var exprs []clause.Expression
+	for _, c := range stmt.Schema.UpdateClauses {
+		if sd, ok := c.(gorm.SoftDeleteUpdateClause); ok {
+			exprs = append(exprs, clause.Eq{Column: clause.Column{Table: e.tableRef(), Name: sd.Field.DBName}, Value: nil})
+		}
+	}
+	return exprs, nil
+}
+
+func (e executor[T]) DeleteUsing(ctx context.Context, other Tabler, on []OpOption, extraFilters ...FilterOption) (uint64, error) {
+	return e.deleteCorrelated(ctx, "EXISTS", other, on, extraFilters)
+}
+
+func (e executor[T]) DeleteUnmatched(ctx context.Context, other Tabler, on []OpOption, extraFilters ...FilterOption) (uint64, error) {
+	return e.deleteCorrelated(ctx, "NOT EXISTS", other, on, extraFilters)
+}
+
+// deleteCorrelated deletes the entities matching the filters for which the subquery of the rows of other matching on
+// and extraFilters satisfies predicate, EXISTS or NOT EXISTS. The correlated subquery is supported by all dialects,
+// and the entities are deleted by the same path as Delete.
+func (e executor[T]) deleteCorrelated(ctx context.Context, predicate string, other Tabler, on []OpOption, extraFilters []FilterOption) (_ uint64, err error) {
+	defer func() { err = e.mapError(err) }()
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+	if e.joined {
+		return 0, errors.New("deleting with other tables is not supported by joined models")
+	}
+	if len(on) == 0 {
+		return 0, errors.New("empty join conditions")
+	}
+	db, err := e.destructiveDB(ctx, e.filters())
+	if err != nil {
+		return 0, err
+	}
+	where, err := e.whereClause(ctx, db, extraFilters)
+	if err != nil {
+		return 0, err
+	}
+	where.Exprs = append([]clause.Expression{joinConditions(db, on)}, where.Exprs...)
+	db = db.Where(clause.Expr{SQL: fmt.Sprintf("%s (SELECT 1 FROM %s WHERE ?)", predicate, tableExpr(other)), Vars: []any{where}})
+	if e.config.tableAlias != "" {
+		// the join conditions refer to the table by its alias.
+		db = db.Table(e.tableExpr())
+	}
+	if e.softDeleteFlag != nil {
+		db = db.Model(new(T)).Update(getColumnName(e.joined, e.softDeleteFlag), true)
+	} else {
+		db = db.Delete(new(T))
+	}
+	return uint64(db.RowsAffected), db.Error
+}
+
+// whereClause builds the WHERE clause of the filter options with qualified column names.
+func (e executor[T]) whereClause(ctx context.Context, db *gorm.DB, opts []FilterOption) (clause.Where, error) {
+	filtered, err := e.newApplyHelper(db.Session(&gorm.Session{NewDB: true}), true).
+		applyFilterOptions(ctx, opts).Result().Get()
+	if err != nil {
+		return clause.Where{}, err
+	}
+	where, _ := filtered.Statement.Clauses["WHERE"].Expression.(clause.Where)
+	return where, nil
+}
+
+// joinConditions returns an expression of the join conditions which are concatenated with AND.
+func joinConditions(db *gorm.DB, on []OpOption) clause.Expr {
+	return clause.Expr{SQL: strings.Join(lo.Map(on, func(opt OpOption, _ int) string {
+		cond := opt.MustLeft()
+		return fmt.Sprintf("%s %s %s", cond.GetLeftColumnName().Full(), dialectQueryOp(db, cond.QueryOp()), cond.GetRightColumnName().Full())
+	}), " AND ")}
 }
@@ -0,0 +1,98 @@
+package sqldb
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// JSONKeyStrategy decides the keys of the JSON object produced by Model.Marshal.
+type JSONKeyStrategy int
+
+const (
+	// JSONKeyFieldName uses Go field names as keys and keeps the nesting of structs, just like json.Marshal.
+	JSONKeyFieldName JSONKeyStrategy = iota
+	// JSONKeyColumnName uses column names as keys in a flat object.
+	JSONKeyColumnName
+	// JSONKeyTag uses the names in json tags as keys in a flat object, column names are used for fields without json tags.
+	// Fields tagged with "-" are omitted by both Marshal and Unmarshal.
+	JSONKeyTag
+)
+
+// WithJSONKeyStrategy sets the JSONKeyStrategy used by Model.Marshal and Model.Unmarshal.
+func WithJSONKeyStrategy(strategy JSONKeyStrategy) ModelOption {
+	return func(c *modelConfig) {
+		c.jsonKeys = strategy
+	}
+}
+
+func (m model[T]) Marshal(entity T) ([]byte, error) {
+	if m.config.jsonKeys == JSONKeyFieldName {
+		return json.Marshal(entity)
+	}
+	object := map[string]json.RawMessage{}
+	if err := m.iterateColumns(&entity, func(column ColumnNameGetter, fieldAddr reflect.Value, sf reflect.StructField) error {
+		key, ok := m.jsonKey(column, sf)
+		if !ok {
+			return nil
+		}
+		if !fieldAddr.IsValid() {
+			object[key] = json.RawMessage("null")
+			return nil
+		}
+		raw, err := json.Marshal(fieldAddr.Interface())
+		if err != nil {
+			return err
+		}
+		object[key] = raw
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return json.Marshal(object)
+}
+
+func (m model[T]) Unmarshal(data []byte, entity *T) error {
+	if m.config.jsonKeys == JSONKeyFieldName {
+		return json.Unmarshal(data, entity)
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+	return m.iterateColumns(entity, func(column ColumnNameGetter, fieldAddr reflect.Value, sf reflect.StructField) error {
+		key, ok := m.jsonKey(column, sf)
+		if !ok {
+			return nil
+		}
+		raw, exist := object[key]
+		if !exist {
+			return nil
+		}
+		if !fieldAddr.IsValid() {
+			if string(raw) == "null" {
+				return nil
+			}
+			var err error
+			if fieldAddr, err = m.allocColumn(entity, column); err != nil {
+				return err
+			}
+		}
+		return json.Unmarshal(raw, fieldAddr.Interface())
+	})
+}
+
+// jsonKey returns the key of the field, ok is false if the field is omitted by the json tag "-".
+// Like encoding/json, the tag "-," names the key "-".
+func (m model[T]) jsonKey(column ColumnNameGetter, sf reflect.StructField) (key string, ok bool) {
+	if m.config.jsonKeys == JSONKeyTag {
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			return "", false
+		}
+		if name, _, _ := strings.Cut(tag, ","); name != "" {
+			return name, true
+		}
+	}
+	return column.GetColumnName().String(), true
+}
@@ -0,0 +1,1055 @@
+// Package memtest provides an in-memory implementation of sqldb.Model for testing code which depends on sqldb.Model,
+// so that the tests do not need a real database.
+package memtest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"github.com/YLonely/sqldb"
+)
+
+// ErrNotSupported is returned by operations which can not be done without a real database.
+var ErrNotSupported = errors.New("not supported by the in-memory model")
+
+// memModel implements the sqldb.Model interface, entities are stored in a slice.
+type memModel[T any] struct {
+	sqldb.Model[T]
+
+	// fields maps column names to the index paths of the corresponding fields.
+	fields map[string][]int
+	mu     *sync.RWMutex
+	rows   *[]T
+}
+
+var _ sqldb.Model[struct{}] = memModel[struct{}]{}
+
+type memExecutor[T any] struct {
+	memModel[T]
+
+	queries    []sqldb.FilterOption
+	distinctOn []sqldb.ColumnNameGetter
+}
+
+// NewMemModel returns an in-memory sqldb.Model, the names of the table and columns are decided by the default
+// naming strategy of GORM.
+//
+// Filters, sorting and pagination are evaluated in memory. Operations that need a real database such as Rows,
+// UpdateFrom, DeleteUsing and DeleteUnmatched return ErrNotSupported, Preload is ignored and Delete removes entities permanently.
+// Updates and deletes without filters are never rejected, regardless of WithSafeDestructive, index predicates
+// and conditions of upserts are ignored and audit columns are not set. Read-only columns are written like other
+// columns since their values can not be generated in memory. The errors of WithErrorMapper are not mapped, and
+// the flag of WithSoftDeleteFlag is ignored so Restore returns ErrNotSupported. Entities are not stored serialized,
+// so filters of RawEQ return ErrNotSupported as well. Scopes of WithScopeIf are ignored.
+func NewMemModel[T any](opts ...sqldb.ModelOption) sqldb.Model[T] {
+	m := memModel[T]{
+		Model:  sqldb.NewModel[T](&gorm.DB{Config: &gorm.Config{NamingStrategy: schema.NamingStrategy{}}}, opts...),
+		fields: map[string][]int{},
+		mu:     &sync.RWMutex{},
+		rows:   &[]T{},
+	}
+	columns := m.Model.Columns()
+	walkColumns(reflect.ValueOf(&columns).Elem(), nil, func(cg sqldb.ColumnNameGetter, index []int) {
+		m.fields[cg.GetColumnName().String()] = index
+	})
+	return m
+}
+
+// walkColumns calls fn with each column field of v and the index path of it.
+func walkColumns(v reflect.Value, index []int, fn func(sqldb.ColumnNameGetter, []int)) {
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		fieldIndex := append(index[:len(index):len(index)], i)
+		field := v.Field(i)
+		if cg, ok := field.Addr().Interface().(sqldb.ColumnNameGetter); ok && field.Kind() == reflect.Struct && field.FieldByName("V").IsValid() {
+			fn(cg, fieldIndex)
+			continue
+		}
+		if field.Kind() == reflect.Struct {
+			walkColumns(field, fieldIndex, fn)
+		}
+	}
+}
+
+func (m memModel[T]) DB(context.Context) *gorm.DB {
+	return nil
+}
+
+func (m memModel[T]) Create(_ context.Context, entity *T) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	*m.rows = append(*m.rows, *entity)
+	return nil
+}
+
+// CreateOrIgnore always creates entity since unique constraints are not enforced in memory.
+func (m memModel[T]) CreateOrIgnore(ctx context.Context, entity *T) (bool, error) {
+	return true, m.Create(ctx, entity)
+}
+
+// CreateReturningID returns ErrNotSupported since ids are not generated in memory, the entity is not created.
+func (memModel[T]) CreateReturningID(context.Context, *T) (int64, error) {
+	return 0, ErrNotSupported
+}
+
+// WithDB returns the model itself since there is no db.
+func (m memModel[T]) WithDB(*gorm.DB) sqldb.Model[T] {
+	return m
+}
+
+// EnsureIndexes does nothing since entities are not indexed in memory.
+func (memModel[T]) EnsureIndexes(context.Context, bool) error {
+	return nil
+}
+
+func (m memModel[T]) Truncate(context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	*m.rows = nil
+	return nil
+}
+
+func (m memModel[T]) Upsert(ctx context.Context, entity *T, conflictColumns, updateColumns []sqldb.ColumnNameGetter, opts ...sqldb.UpsertOption) error {
+	return m.UpsertInBatches(ctx, []*T{entity}, conflictColumns, updateColumns, 1, opts...)
+}
+
+func (m memModel[T]) UpsertInBatches(_ context.Context, entities []*T, conflictColumns, updateColumns []sqldb.ColumnNameGetter, _ int, _ ...sqldb.UpsertOption) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rows := append([]T(nil), *m.rows...)
+	for _, entity := range entities {
+		conflicted := -1
+		for i := range rows {
+			equal := true
+			for _, column := range conflictColumns {
+				existing, err := m.value(&rows[i], column)
+				if err != nil {
+					return err
+				}
+				v, err := m.value(entity, column)
+				if err != nil {
+					return err
+				}
+				if !reflect.DeepEqual(existing, v) {
+					equal = false
+					break
+				}
+			}
+			if equal {
+				conflicted = i
+				break
+			}
+		}
+		if conflicted < 0 {
+			rows = append(rows, *entity)
+			continue
+		}
+		for _, column := range updateColumns {
+			dst, err := m.field(&rows[conflicted], column)
+			if err != nil {
+				return err
+			}
+			src, err := m.field(entity, column)
+			if err != nil {
+				return err
+			}
+			dst.Set(src)
+		}
+	}
+	*m.rows = rows
+	return nil
+}
+
+func (m memModel[T]) Query(queries ...sqldb.FilterOption) sqldb.Executor[T] {
+	return memExecutor[T]{memModel: m, queries: queries}
+}
+
+// UpdateDiff finds the entity by the primary key columns of oldEntity and updates the columns of newEntity whose
+// values differ. Primary keys must be columns since plain fields can not be filtered in memory, otherwise an error is
+// returned rather than updating every entity.
+func (m memModel[T]) UpdateDiff(ctx context.Context, oldEntity, newEntity *T) (uint64, error) {
+	s, err := schema.Parse(new(T), &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		return 0, err
+	}
+	if len(s.PrimaryFieldDBNames) == 0 {
+		return 0, fmt.Errorf("%s has no primary key", m.Table())
+	}
+	var (
+		filters []sqldb.FilterOption
+		updates []sqldb.UpdateOption
+	)
+	for _, column := range m.ColumnNames() {
+		ov, err := m.value(oldEntity, column)
+		if err != nil {
+			return 0, err
+		}
+		nv, err := m.value(newEntity, column)
+		if err != nil {
+			return 0, err
+		}
+		if lo.Contains(s.PrimaryFieldDBNames, column.GetColumnName().String()) {
+			filters = append(filters, sqldb.NewOpQueryOption(column.GetColumnName(), sqldb.OpEq, ov))
+		} else if !reflect.DeepEqual(ov, nv) {
+			updates = append(updates, sqldb.NewUpdateOption(column.GetColumnName(), nv))
+		}
+	}
+	if len(filters) != len(s.PrimaryFieldDBNames) {
+		return 0, fmt.Errorf("the primary keys of %s are not all columns", m.Table())
+	}
+	if len(updates) == 0 {
+		return 0, nil
+	}
+	return m.Query(filters...).Update(ctx, updates...)
+}
+
+// value returns the value of the column of entity, pointers are dereferenced and nil is returned for NULL.
+func (m memModel[T]) value(entity *T, column sqldb.ColumnNameGetter) (any, error) {
+	v, err := m.field(entity, column)
+	if err != nil {
+		return nil, err
+	}
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	return v.Interface(), nil
+}
+
+// field returns the addressable value V of the column field of entity.
+func (m memModel[T]) field(entity *T, column sqldb.ColumnNameGetter) (reflect.Value, error) {
+	index, exist := m.fields[column.GetColumnName().String()]
+	if !exist {
+		return reflect.Value{}, fmt.Errorf("unknown column %s", column.GetColumnName())
+	}
+	return reflect.ValueOf(entity).Elem().FieldByIndex(index).FieldByName("V"), nil
+}
+
+func (e memExecutor[T]) Get(ctx context.Context) (T, error) {
+	entities, err := e.Find(ctx, sqldb.ListOptions{Limit: 1})
+	if err != nil {
+		return lo.Empty[T](), err
+	}
+	if len(entities) == 0 {
+		return lo.Empty[T](), gorm.ErrRecordNotFound
+	}
+	return entities[0], nil
+}
+
+// DequeueOne returns the first entity matching the filters and extraFilters, there is no locking in memory.
+func (e memExecutor[T]) DequeueOne(ctx context.Context, extraFilters ...sqldb.FilterOption) (T, error) {
+	e.queries = append(e.queries[:len(e.queries):len(e.queries)], extraFilters...)
+	return e.Get(ctx)
+}
+
+func (e memExecutor[T]) Pager(pageSize uint64, col sqldb.ColumnNameGetter) *sqldb.Pager[T] {
+	return sqldb.NewPager[T](e, pageSize, col, func(entity T) (any, error) {
+		return e.value(&entity, col)
+	})
+}
+
+func (e memExecutor[T]) MaxBy(ctx context.Context, col sqldb.ColumnNameGetter) (T, error) {
+	return e.firstBy(ctx, col, sqldb.SortOrderDescending)
+}
+
+func (e memExecutor[T]) MinBy(ctx context.Context, col sqldb.ColumnNameGetter) (T, error) {
+	return e.firstBy(ctx, col, sqldb.SortOrderAscending)
+}
+
+func (e memExecutor[T]) firstBy(ctx context.Context, col sqldb.ColumnNameGetter, order sqldb.SortOrder) (T, error) {
+	entities, err := e.Find(ctx, sqldb.ListOptions{Limit: 1, SortOptions: []sqldb.SortOption{sqldb.NewSortOption(col.GetColumnName(), order)}})
+	if err != nil {
+		return lo.Empty[T](), err
+	}
+	if len(entities) == 0 {
+		return lo.Empty[T](), gorm.ErrRecordNotFound
+	}
+	return entities[0], nil
+}
+
+func (e memExecutor[T]) List(ctx context.Context, opts sqldb.ListOptions) ([]T, uint64, error) {
+	var entities []T
+	total, err := e.ListInto(ctx, opts, &entities)
+	return entities, total, err
+}
+
+func (e memExecutor[T]) Find(ctx context.Context, opts sqldb.ListOptions) ([]T, error) {
+	var entities []T
+	_, err := e.ListInto(ctx, opts, &entities)
+	return entities, err
+}
+
+func (e memExecutor[T]) ListInto(_ context.Context, opts sqldb.ListOptions, dest *[]T) (uint64, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	matched, err := e.match()
+	if err != nil {
+		return 0, err
+	}
+	entities := lo.Map(matched, func(i int, _ int) T { return (*e.rows)[i] })
+	if err := e.sort(entities, opts.SortOptions); err != nil {
+		return 0, err
+	}
+	if len(e.distinctOn) != 0 {
+		if entities, err = e.distinct(entities); err != nil {
+			return 0, err
+		}
+	}
+	total := uint64(len(entities))
+	if opts.Offset >= uint64(len(entities)) {
+		entities = nil
+	} else {
+		entities = entities[opts.Offset:]
+	}
+	if opts.Limit != 0 && opts.Limit < uint64(len(entities)) {
+		entities = entities[:opts.Limit]
+	}
+	if len(opts.Columns) != 0 {
+		if entities, err = e.project(entities, opts.Columns); err != nil {
+			return 0, err
+		}
+	}
+	*dest = append((*dest)[:0], entities...)
+	return total, nil
+}
+
+// project returns copies of entities in which only the given columns are kept.
+func (e memExecutor[T]) project(entities []T, columns []sqldb.ColumnNameGetter) ([]T, error) {
+	return sqldb.MapErr(entities, func(entity T, _ int) (T, error) {
+		var projected T
+		for _, column := range columns {
+			src, err := e.field(&entity, column)
+			if err != nil {
+				return projected, err
+			}
+			dst, _ := e.field(&projected, column)
+			dst.Set(src)
+		}
+		return projected, nil
+	})
+}
+
+// ListRaw returns the Go values of the columns, which are not converted into driver values.
+func (e memExecutor[T]) ListRaw(ctx context.Context, opts sqldb.ListOptions) ([]map[string]any, uint64, error) {
+	entities, total, err := e.List(ctx, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	rows, err := sqldb.MapErr(entities, func(entity T, _ int) (map[string]any, error) {
+		row := map[string]any{}
+		for _, column := range e.ColumnNames() {
+			v, err := e.value(&entity, column)
+			if err != nil {
+				return nil, err
+			}
+			row[column.GetColumnName().String()] = v
+		}
+		return row, nil
+	})
+	return rows, total, err
+}
+
+func (e memExecutor[T]) ListWithAggregate(ctx context.Context, opts sqldb.ListOptions, aggs ...sqldb.Aggregate) ([]T, map[string]any, uint64, error) {
+	entities, total, err := e.List(ctx, opts)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	matched, err := e.match()
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	results := make(map[string]any, len(aggs))
+	for _, agg := range aggs {
+		if agg.As == nil {
+			return nil, nil, 0, fmt.Errorf("alias of aggregate %s is missing", agg.Func)
+		}
+		values := make([]any, 0, len(matched))
+		for _, i := range matched {
+			if agg.Column == nil {
+				values = append(values, true)
+				continue
+			}
+			v, err := e.value(&(*e.rows)[i], agg.Column)
+			if err != nil {
+				return nil, nil, 0, err
+			}
+			if v = indirect(v); v != nil {
+				values = append(values, v)
+			}
+		}
+		if results[agg.As.GetColumnName().Name], err = aggregate(agg.Func, values); err != nil {
+			return nil, nil, 0, err
+		}
+	}
+	return entities, results, total, nil
+}
+
+// aggregate computes fn over the non-NULL values, COUNT returns an int64 and SUM and AVG are computed in float64.
+func aggregate(fn sqldb.AggregateFunc, values []any) (any, error) {
+	if fn == sqldb.AggregateCount {
+		return int64(len(values)), nil
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+	switch fn {
+	case sqldb.AggregateSum, sqldb.AggregateAvg:
+		var sum float64
+		for _, v := range values {
+			rv := reflect.ValueOf(v)
+			if !isNumber(rv) {
+				return nil, fmt.Errorf("%s is not supported by values of type %T", fn, v)
+			}
+			sum += toFloat(rv)
+		}
+		return lo.Ternary(fn == sqldb.AggregateAvg, sum/float64(len(values)), sum), nil
+	case sqldb.AggregateMin, sqldb.AggregateMax:
+		result := values[0]
+		for _, v := range values[1:] {
+			c, ok := compare(v, result)
+			if !ok {
+				return nil, fmt.Errorf("values of type %T are not comparable by %s", v, fn)
+			}
+			if fn == sqldb.AggregateMin && c < 0 || fn == sqldb.AggregateMax && c > 0 {
+				result = v
+			}
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("unsupported aggregate function %s", fn)
+}
+
+func (e memExecutor[T]) Update(_ context.Context, opts ...sqldb.UpdateOption) (uint64, error) {
+	if len(opts) == 0 {
+		return 0, errors.New("empty options")
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	matched, err := e.match()
+	if err != nil {
+		return 0, err
+	}
+	for _, i := range matched {
+		entity := (*e.rows)[i]
+		for _, opt := range opts {
+			if err := e.set(&entity, opt); err != nil {
+				return 0, err
+			}
+		}
+		(*e.rows)[i] = entity
+	}
+	return uint64(len(matched)), nil
+}
+
+func (e memExecutor[T]) set(entity *T, opt sqldb.UpdateOption) error {
+	field, err := e.field(entity, opt)
+	if err != nil {
+		return err
+	}
+	value := opt.GetValue()
+	switch v := value.(type) {
+	case sqldb.ColumnRef:
+		src, err := e.field(entity, v)
+		if err != nil {
+			return err
+		}
+		value = src.Interface()
+	case sqldb.FlagUpdate:
+		bits, ok := integerBits(field.Interface())
+		if !ok {
+			// flags of NULL are NULL.
+			field.Set(reflect.Zero(field.Type()))
+			return nil
+		}
+		value = lo.Ternary(v.Clear, bits&^v.Mask, bits|v.Mask)
+	case clause.Expr, sqldb.Subquery:
+		return ErrNotSupported
+	}
+	if value == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+	rv := reflect.ValueOf(value)
+	if field.Kind() == reflect.Pointer && rv.Kind() != reflect.Pointer && rv.CanConvert(field.Type().Elem()) {
+		ptr := reflect.New(field.Type().Elem())
+		ptr.Elem().Set(rv.Convert(field.Type().Elem()))
+		field.Set(ptr)
+		return nil
+	}
+	if !rv.CanConvert(field.Type()) {
+		return fmt.Errorf("unable to convert value of type %s to the column type %s", rv.Type(), field.Type())
+	}
+	field.Set(rv.Convert(field.Type()))
+	return nil
+}
+
+func (e memExecutor[T]) Delete(context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	matched, err := e.match()
+	if err != nil {
+		return err
+	}
+	*e.rows = lo.Reject(*e.rows, func(_ T, i int) bool { return lo.Contains(matched, i) })
+	return nil
+}
+
+// DeleteInBatches deletes the matched entities at once since there are no locks to release between batches.
+func (e memExecutor[T]) DeleteInBatches(_ context.Context, batchSize int) (uint64, error) {
+	if batchSize <= 0 {
+		return 0, errors.New("batch size must be positive")
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	matched, err := e.match()
+	if err != nil {
+		return 0, err
+	}
+	*e.rows = lo.Reject(*e.rows, func(_ T, i int) bool { return lo.Contains(matched, i) })
+	return uint64(len(matched)), nil
+}
+
+// Restore returns ErrNotSupported since Delete removes entities permanently.
+func (e memExecutor[T]) Restore(context.Context) (uint64, error) {
+	return 0, ErrNotSupported
+}
+
+func (e memExecutor[T]) AllowGlobalUpdate() sqldb.Executor[T] {
+	return e
+}
+
+func (e memExecutor[T]) DistinctOn(cols ...sqldb.ColumnNameGetter) sqldb.Executor[T] {
+	e.distinctOn = cols
+	return e
+}
+
+// WithIndexHint is ignored since entities are not indexed in memory.
+func (e memExecutor[T]) WithIndexHint(string) sqldb.Executor[T] {
+	return e
+}
+
+func (e memExecutor[T]) Preload(...string) sqldb.Executor[T] {
+	return e
+}
+
+func (e memExecutor[T]) Apply(scopes ...sqldb.Scope) sqldb.Executor[T] {
+	e.queries = e.queries[:len(e.queries):len(e.queries)]
+	for _, scope := range scopes {
+		e.queries = append(e.queries, scope()...)
+	}
+	return e
+}
+
+func (e memExecutor[T]) DescribeFilters() []sqldb.FilterDescriptor {
+	return sqldb.DescribeFilters(e.queries)
+}
+
+func (e memExecutor[T]) Stream(ctx context.Context, opts sqldb.ListOptions) (<-chan T, <-chan error) {
+	entities, errs := make(chan T), make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(entities)
+		found, err := e.Find(ctx, opts)
+		if err != nil {
+			errs <- err
+			return
+		}
+		for _, entity := range found {
+			select {
+			case entities <- entity:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+	}()
+	return entities, errs
+}
+
+// CountBy keys the counts by the values of the column fields rather than the values returned by drivers.
+func (e memExecutor[T]) CountBy(_ context.Context, col sqldb.ColumnNameGetter, extraFilters ...sqldb.FilterOption) (map[any]uint64, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	e.queries = append(e.queries[:len(e.queries):len(e.queries)], extraFilters...)
+	matched, err := e.match()
+	if err != nil {
+		return nil, err
+	}
+	counts := map[any]uint64{}
+	for _, i := range matched {
+		v, err := e.value(&(*e.rows)[i], col)
+		if err != nil {
+			return nil, err
+		}
+		counts[v]++
+	}
+	return counts, nil
+}
+
+// DistinctCountBy keys the counts by the values of the column fields like CountBy.
+func (e memExecutor[T]) DistinctCountBy(_ context.Context, groupCol, distinctCol sqldb.ColumnNameGetter, extraFilters ...sqldb.FilterOption) (map[any]uint64, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	e.queries = append(e.queries[:len(e.queries):len(e.queries)], extraFilters...)
+	matched, err := e.match()
+	if err != nil {
+		return nil, err
+	}
+	distinct := map[any]map[any]bool{}
+	for _, i := range matched {
+		key, err := e.value(&(*e.rows)[i], groupCol)
+		if err != nil {
+			return nil, err
+		}
+		v, err := e.value(&(*e.rows)[i], distinctCol)
+		if err != nil {
+			return nil, err
+		}
+		if distinct[key] == nil {
+			distinct[key] = map[any]bool{}
+		}
+		// NULL is not counted but the group still exists.
+		if v != nil {
+			distinct[key][v] = true
+		}
+	}
+	counts := make(map[any]uint64, len(distinct))
+	for key, values := range distinct {
+		counts[key] = uint64(len(values))
+	}
+	return counts, nil
+}
+
+func (e memExecutor[T]) Histogram(_ context.Context, col sqldb.ColumnNameGetter, buckets []sqldb.Bucket) ([]sqldb.BucketCount, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	matched, err := e.match()
+	if err != nil {
+		return nil, err
+	}
+	counts := lo.Map(buckets, func(b sqldb.Bucket, _ int) sqldb.BucketCount { return sqldb.BucketCount{Bucket: b} })
+	for _, i := range matched {
+		v, err := e.value(&(*e.rows)[i], col)
+		if err != nil {
+			return nil, err
+		}
+		for j, b := range buckets {
+			if in, err := inBucket(v, b); err != nil {
+				return nil, err
+			} else if in {
+				counts[j].Count++
+				break
+			}
+		}
+	}
+	return counts, nil
+}
+
+// inBucket reports whether v is in the half-open range of b, NULL is in no bucket.
+func inBucket(v any, b sqldb.Bucket) (bool, error) {
+	if b.From != nil {
+		if ok, err := evaluate(v, sqldb.OpGte, b.From); err != nil || !ok {
+			return false, err
+		}
+	}
+	if b.To != nil {
+		if ok, err := evaluate(v, sqldb.OpLt, b.To); err != nil || !ok {
+			return false, err
+		}
+	}
+	return v != nil, nil
+}
+
+func (e memExecutor[T]) Rows(context.Context, sqldb.ListOptions) (*sql.Rows, error) {
+	return nil, ErrNotSupported
+}
+
+func (e memExecutor[T]) UpdateFrom(context.Context, sqldb.Tabler, []sqldb.OpOption, ...sqldb.UpdateOption) (uint64, error) {
+	return 0, ErrNotSupported
+}
+
+func (e memExecutor[T]) DeleteUsing(context.Context, sqldb.Tabler, []sqldb.OpOption, ...sqldb.FilterOption) (uint64, error) {
+	return 0, ErrNotSupported
+}
+
+func (e memExecutor[T]) DeleteUnmatched(context.Context, sqldb.Tabler, []sqldb.OpOption, ...sqldb.FilterOption) (uint64, error) {
+	return 0, ErrNotSupported
+}
+
+// Export only supports sqldb.ExportFormatNDJSON.
+func (e memExecutor[T]) Export(ctx context.Context, opts sqldb.ListOptions, w io.Writer, format sqldb.ExportFormat) error {
+	if format != sqldb.ExportFormatNDJSON {
+		return ErrNotSupported
+	}
+	entities, err := e.Find(ctx, opts)
+	if err != nil {
+		return err
+	}
+	for _, entity := range entities {
+		raw, err := e.Marshal(entity)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(append(raw, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// match returns the indexes of the stored entities matching all filters of the executor.
+func (e memExecutor[T]) match() ([]int, error) {
+	var matched []int
+	for i := range *e.rows {
+		ok := true
+		for _, query := range e.queries {
+			var err error
+			if ok, err = e.matchFilter(&(*e.rows)[i], query); err != nil {
+				return nil, err
+			}
+			if !ok {
+				break
+			}
+		}
+		if ok {
+			matched = append(matched, i)
+		}
+	}
+	return matched, nil
+}
+
+func (e memExecutor[T]) matchFilter(entity *T, query sqldb.FilterOption) (bool, error) {
+	switch opt := query.(type) {
+	case sqldb.OpOption:
+		if opt.IsLeft() {
+			cond := opt.MustLeft()
+			left, err := e.value(entity, cond.GetLeftColumnName())
+			if err != nil {
+				return false, err
+			}
+			right, err := e.value(entity, cond.GetRightColumnName())
+			if err != nil {
+				return false, err
+			}
+			return evaluate(left, cond.QueryOp(), right)
+		}
+		cond := opt.MustRight()
+		if _, ok := cond.GetValue().(clause.Expr); ok {
+			return false, ErrNotSupported
+		}
+		v, err := e.value(entity, cond)
+		if err != nil {
+			return false, err
+		}
+		return evaluate(v, cond.QueryOp(), cond.GetValue())
+	case sqldb.RangeQueryOption:
+		v, err := e.value(entity, opt)
+		if err != nil || v == nil {
+			return false, err
+		}
+		in := lo.ContainsBy(opt.GetValues(), func(value any) bool {
+			c, ok := compare(v, value)
+			return ok && c == 0
+		})
+		return in != opt.Exclude(), nil
+	case sqldb.GroupOption:
+		matched := 0
+		for _, member := range opt.GetFilterOptions() {
+			ok, err := e.matchFilter(entity, member)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				matched++
+			}
+		}
+		switch opt.GroupOp() {
+		case sqldb.GroupOpOr:
+			return matched != 0, nil
+		case sqldb.GroupOpNot:
+			return matched != len(opt.GetFilterOptions()), nil
+		default:
+			return matched == len(opt.GetFilterOptions()), nil
+		}
+	case sqldb.FuzzyQueryOption:
+		v, err := e.value(entity, opt)
+		if err != nil || v == nil {
+			return false, err
+		}
+		return lo.ContainsBy(opt.GetValues(), func(value any) bool {
+			return strings.Contains(fmt.Sprint(v), fmt.Sprint(value))
+		}), nil
+	default:
+		return false, fmt.Errorf("unsupported filter option type %s", query.GetFilterOptionType())
+	}
+}
+
+// evaluate compares v with value using op, comparing with NULL is always false except for sqldb.OpEqNullSafe.
+func evaluate(v any, op sqldb.QueryOp, value any) (bool, error) {
+	if q, ok := value.(sqldb.QuantifiedValues); ok {
+		for _, value := range q.Values {
+			ok, err := evaluate(v, op, value)
+			if err != nil {
+				return false, err
+			}
+			if ok == (q.Quantifier == sqldb.QuantifierAny) {
+				return ok, nil
+			}
+		}
+		return q.Quantifier == sqldb.QuantifierAll, nil
+	}
+	if _, ok := value.(sqldb.RawValue); ok {
+		// entities are not stored in the serialized form.
+		return false, ErrNotSupported
+	}
+	if op == sqldb.OpJSONContains {
+		return jsonContains(v, value)
+	}
+	if op == sqldb.OpHasFlag {
+		bits, ok := integerBits(v)
+		return ok && bits&value.(uint64) == value.(uint64), nil
+	}
+	if v == nil || value == nil {
+		return op == sqldb.OpEqNullSafe && v == nil && value == nil, nil
+	}
+	c, ok := compare(v, value)
+	if !ok {
+		if op == sqldb.OpEq || op == sqldb.OpEqNullSafe || op == sqldb.OpNe {
+			return (op == sqldb.OpNe) != reflect.DeepEqual(v, value), nil
+		}
+		return false, fmt.Errorf("unable to compare %v with %v", v, value)
+	}
+	switch op {
+	case sqldb.OpEq, sqldb.OpEqNullSafe:
+		return c == 0, nil
+	case sqldb.OpNe:
+		return c != 0, nil
+	case sqldb.OpGt:
+		return c > 0, nil
+	case sqldb.OpLt:
+		return c < 0, nil
+	case sqldb.OpGte:
+		return c >= 0, nil
+	case sqldb.OpLte:
+		return c <= 0, nil
+	default:
+		return false, fmt.Errorf("unsupported query operator %s", op)
+	}
+}
+
+// integerBits returns the bits of the integer v, it returns false if v is NULL.
+func integerBits(v any) (uint64, bool) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return uint64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint(), true
+	default:
+		return 0, false
+	}
+}
+
+// jsonContains reports whether the JSON encoding of v contains the JSON encoding of value like the JSONB @> operator.
+func jsonContains(v, value any) (bool, error) {
+	decode := func(v any) (any, error) {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		var decoded any
+		return decoded, json.Unmarshal(raw, &decoded)
+	}
+	a, err := decode(v)
+	if err != nil {
+		return false, err
+	}
+	b, err := decode(value)
+	if err != nil {
+		return false, err
+	}
+	return contains(a, b), nil
+}
+
+func contains(a, b any) bool {
+	switch vb := b.(type) {
+	case map[string]any:
+		va, ok := a.(map[string]any)
+		if !ok {
+			return false
+		}
+		for k, v := range vb {
+			if sub, exist := va[k]; !exist || !contains(sub, v) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		va, ok := a.([]any)
+		if !ok {
+			return false
+		}
+		return lo.EveryBy(vb, func(e any) bool {
+			return lo.ContainsBy(va, func(sub any) bool { return contains(sub, e) })
+		})
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
+
+// compare returns -1, 0 or 1 if a is less than, equal to or greater than b, false is returned if they are not comparable.
+func compare(a, b any) (int, bool) {
+	a, b = indirect(a), indirect(b)
+	if a == nil || b == nil {
+		return 0, false
+	}
+	ra, rb := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch {
+	case isNumber(ra) && isNumber(rb):
+		fa, fb := toFloat(ra), toFloat(rb)
+		return lo.Ternary(fa < fb, -1, lo.Ternary(fa > fb, 1, 0)), true
+	case ra.Kind() == reflect.String && rb.Kind() == reflect.String:
+		return strings.Compare(ra.String(), rb.String()), true
+	}
+	ta, okA := a.(time.Time)
+	tb, okB := b.(time.Time)
+	if okA && okB {
+		return lo.Ternary(ta.Before(tb), -1, lo.Ternary(ta.After(tb), 1, 0)), true
+	}
+	if reflect.DeepEqual(a, b) {
+		return 0, true
+	}
+	return 0, false
+}
+
+// indirect dereferences pointers and resolves the values of driver.Valuer.
+func indirect(v any) any {
+	if valuer, ok := v.(driver.Valuer); ok {
+		if value, err := valuer.Value(); err == nil {
+			return value
+		}
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+	return rv.Interface()
+}
+
+func isNumber(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func toFloat(v reflect.Value) float64 {
+	switch {
+	case v.CanInt():
+		return float64(v.Int())
+	case v.CanUint():
+		return float64(v.Uint())
+	default:
+		return v.Float()
+	}
+}
+
+func (e memExecutor[T]) sort(entities []T, opts []sqldb.SortOption) error {
+	var err error
+	sort.SliceStable(entities, func(i, j int) bool {
+		for _, opt := range opts {
+			a, errA := e.value(&entities[i], opt)
+			b, errB := e.value(&entities[j], opt)
+			if errA != nil || errB != nil {
+				err = lo.Ternary(errA != nil, errA, errB)
+				return false
+			}
+			if valuesOpt, ok := opt.(sqldb.ValuesSortOption); ok {
+				a, b = position(valuesOpt.GetValues(), a), position(valuesOpt.GetValues(), b)
+			}
+			// NULL is considered smaller than any value.
+			if a == nil || b == nil {
+				if a == nil && b == nil {
+					continue
+				}
+				return (a == nil) != (opt.GetSortOrder() == sqldb.SortOrderDescending)
+			}
+			c, _ := compare(a, b)
+			if c == 0 {
+				continue
+			}
+			return (c < 0) != (opt.GetSortOrder() == sqldb.SortOrderDescending)
+		}
+		return false
+	})
+	return err
+}
+
+// position returns the index of v in values, len(values) is returned if v is not in values.
+func position(values []any, v any) int {
+	for i, value := range values {
+		if c, ok := compare(v, value); ok && c == 0 {
+			return i
+		}
+	}
+	return len(values)
+}
+
+// distinct keeps the first entity of each set of entities where the DISTINCT ON columns are equal.
+func (e memExecutor[T]) distinct(entities []T) ([]T, error) {
+	var (
+		seen = map[string]struct{}{}
+		res  []T
+	)
+	for i := range entities {
+		values, err := sqldb.MapErr(e.distinctOn, func(cg sqldb.ColumnNameGetter, _ int) (any, error) {
+			return e.value(&entities[i], cg)
+		})
+		if err != nil {
+			return nil, err
+		}
+		raw, err := json.Marshal(values)
+		if err != nil {
+			return nil, err
+		}
+		if _, exist := seen[string(raw)]; !exist {
+			seen[string(raw)] = struct{}{}
+			res = append(res, entities[i])
+		}
+	}
+	return res, nil
+}
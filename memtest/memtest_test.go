@@ -0,0 +1,360 @@
+package memtest
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"github.com/YLonely/sqldb"
+)
+
+type User struct {
+	ID      sqldb.Column[uint64] `gorm:"column:id;primaryKey"`
+	Name    sqldb.Column[string] `gorm:"column:user_name"`
+	Age     sqldb.Column[int]
+	Address sqldb.PtrColumn[string]
+	Status  sqldb.Column[Status] `gorm:"serializer:json"`
+}
+
+type Status struct {
+	Occupation string
+}
+
+func newUser(id uint64, name string, age int, addr string, occupation string) *User {
+	return &User{
+		ID:      sqldb.NewColumn(id),
+		Name:    sqldb.NewColumn(name),
+		Age:     sqldb.NewColumn(age),
+		Address: sqldb.NewPtrColumn(addr),
+		Status:  sqldb.NewColumn(Status{Occupation: occupation}),
+	}
+}
+
+var (
+	ctx = context.Background()
+	u1  = newUser(1, "William K Turner", 46, "2824 Davis Court", "Health Educator")
+	u2  = newUser(2, "Jillian B Bennett", 49, "4209 Ingram Street", "Refrigeration Mechanic")
+	u3  = newUser(3, "Sebastian Turner", 30, "Michigan, Billings", "Teacher")
+	u4  = newUser(4, "Vera Crawford", 29, "4431 Jefferson Street", "Teacher")
+)
+
+func newModel(t *testing.T) sqldb.Model[User] {
+	m := NewMemModel[User]()
+	for _, u := range []*User{u1, u2, u3, u4} {
+		assert.Nil(t, m.Create(ctx, u))
+	}
+	return m
+}
+
+func TestList(t *testing.T) {
+	m := newModel(t)
+	cols := m.Columns()
+	assert.Equal(t, "users", m.Table())
+	assert.Equal(t, "user_name", cols.Name.String())
+
+	for index, c := range []struct {
+		queries     []sqldb.FilterOption
+		opts        sqldb.ListOptions
+		expectTotal uint64
+		expect      []User
+	}{
+		{
+			queries:     []sqldb.FilterOption{cols.Status.EQ(Status{Occupation: "Teacher"})},
+			expectTotal: 2,
+			expect:      []User{*u3, *u4},
+		},
+		{
+			queries: []sqldb.FilterOption{cols.Age.GTE(30), cols.Name.FuzzyIn([]string{"Turner", "Bennett"})},
+			opts: sqldb.ListOptions{
+				SortOptions: []sqldb.SortOption{cols.Age.Sort(sqldb.SortOrderDescending)},
+				Limit:       2,
+			},
+			expectTotal: 3,
+			expect:      []User{*u2, *u1},
+		},
+		{
+			queries:     []sqldb.FilterOption{cols.ID.NotIn([]uint64{1, 2}), cols.Address.FuzzyIn([]string{"Street"})},
+			expectTotal: 1,
+			expect:      []User{*u4},
+		},
+		{
+			queries: []sqldb.FilterOption{cols.ID.In([]uint64{1, 2, 3})},
+			opts: sqldb.ListOptions{
+				SortOptions: []sqldb.SortOption{cols.ID.Sort(sqldb.SortOrderAscending)},
+				Offset:      1,
+			},
+			expectTotal: 3,
+			expect:      []User{*u2, *u3},
+		},
+		{
+			queries:     []sqldb.FilterOption{sqldb.Not(sqldb.Or(cols.ID.EQ(uint64(1)), cols.Age.GT(45)))},
+			expectTotal: 2,
+			expect:      []User{*u3, *u4},
+		},
+	} {
+		users, total, err := m.Query(c.queries...).List(ctx, c.opts)
+		assert.Nil(t, err, err)
+		assert.Equal(t, c.expectTotal, total, "index %d", index)
+		assert.EqualValues(t, c.expect, users, "index %d", index)
+	}
+
+	users, err := m.Query(cols.Status.JSONBContains(map[string]any{"Occupation": "Teacher"})).Find(ctx, sqldb.ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{*u3, *u4}, users)
+
+	users, err = m.Query().DistinctOn(cols.Status).Find(ctx, sqldb.ListOptions{
+		SortOptions: []sqldb.SortOption{cols.Status.Sort(sqldb.SortOrderAscending), cols.Age.Sort(sqldb.SortOrderAscending)},
+	})
+	assert.Nil(t, err, err)
+	assert.Len(t, users, 3)
+	users, total, err := m.Query().DistinctOn(cols.Status).List(ctx, sqldb.ListOptions{
+		SortOptions: []sqldb.SortOption{cols.Status.Sort(sqldb.SortOrderAscending)}, Limit: 1,
+	})
+	assert.Nil(t, err, err)
+	assert.Len(t, users, 1)
+	assert.Equal(t, uint64(3), total)
+}
+
+func TestUpdateAndDelete(t *testing.T) {
+	m := newModel(t)
+	cols := m.Columns()
+
+	updated, err := m.Query(cols.Age.LT(40)).Update(ctx, cols.Name.Update("young"), cols.Age.Update(sqldb.Ref(cols.ID)))
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(2), updated)
+	user, err := m.Query(cols.ID.EQ(4)).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, "young", user.Name.V)
+	assert.Equal(t, 4, user.Age.V)
+
+	assert.Nil(t, m.Query(cols.Name.EQ("young")).Delete(ctx))
+	_, err = m.Query(cols.ID.EQ(4)).Get(ctx)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, total, err := m.Query().List(ctx, sqldb.ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(2), total)
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, m.Query().Export(ctx, sqldb.ListOptions{}, buf, sqldb.ExportFormatNDJSON))
+	assert.Len(t, strings.Split(strings.TrimSpace(buf.String()), "\n"), 2)
+
+	_, err = m.Query().Rows(ctx, sqldb.ListOptions{})
+	assert.ErrorIs(t, err, ErrNotSupported)
+}
+
+func TestUpsertInBatches(t *testing.T) {
+	m := newModel(t)
+	cols := m.Columns()
+
+	updated, created := *u1, newUser(5, "Lea Morgan", 33, "12 Hill Road", "Pilot")
+	updated.Age = sqldb.NewColumn(50)
+	assert.Nil(t, m.UpsertInBatches(ctx, []*User{&updated, created}, []sqldb.ColumnNameGetter{cols.ID}, []sqldb.ColumnNameGetter{cols.Age}, 1))
+
+	users, err := m.Query(cols.ID.In([]uint64{1, 5})).Find(ctx, sqldb.ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{updated, *created}, users)
+}
+
+func TestCountBy(t *testing.T) {
+	m := newModel(t)
+	cols := m.Columns()
+
+	counts, err := m.Query(cols.Age.LT(48)).CountBy(ctx, cols.Status)
+	assert.Nil(t, err, err)
+	assert.Equal(t, map[any]uint64{Status{Occupation: "Teacher"}: 2, Status{Occupation: "Health Educator"}: 1}, counts)
+}
+
+func TestDistinctCountBy(t *testing.T) {
+	m := newModel(t)
+	cols := m.Columns()
+
+	counts, err := m.Query().DistinctCountBy(ctx, cols.Status, cols.Age)
+	assert.Nil(t, err, err)
+	assert.Equal(t, map[any]uint64{
+		Status{Occupation: "Teacher"}:                2,
+		Status{Occupation: "Health Educator"}:        1,
+		Status{Occupation: "Refrigeration Mechanic"}: 1,
+	}, counts)
+}
+
+func TestFuzzyInNonString(t *testing.T) {
+	m := newModel(t)
+	assert.Panics(t, func() { m.Query(m.Columns().Age.FuzzyIn([]int{4})) })
+}
+
+func TestMaxByMinBy(t *testing.T) {
+	m := newModel(t)
+	cols := m.Columns()
+
+	user, err := m.Query().MaxBy(ctx, cols.Age)
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(2), user.ID.V)
+	user, err = m.Query().MinBy(ctx, cols.Age)
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(4), user.ID.V)
+}
+
+func TestUpdateDiff(t *testing.T) {
+	m := newModel(t)
+	cols := m.Columns()
+	old, err := m.Query(cols.ID.EQ(uint64(1))).Get(ctx)
+	assert.Nil(t, err, err)
+	updated := old
+	updated.Age = sqldb.NewColumn(47)
+	n, err := m.UpdateDiff(ctx, &old, &updated)
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(1), n)
+	user, err := m.Query(cols.ID.EQ(uint64(1))).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, updated, user)
+
+	type tag struct {
+		gorm.Model
+		Name sqldb.Column[string]
+	}
+	tags := NewMemModel[tag]()
+	assert.Nil(t, tags.Create(ctx, &tag{Name: sqldb.NewColumn("a")}))
+	_, err = tags.UpdateDiff(ctx, &tag{}, &tag{Name: sqldb.NewColumn("b")})
+	assert.EqualError(t, err, "the primary keys of tags are not all columns")
+}
+
+func TestListColumns(t *testing.T) {
+	m := newModel(t)
+	cols := m.Columns()
+	users, err := m.Query(cols.ID.EQ(uint64(1))).Find(ctx, sqldb.ListOptions{Columns: []sqldb.ColumnNameGetter{cols.ID, cols.Age}})
+	assert.Nil(t, err, err)
+	assert.Equal(t, []User{{ID: sqldb.NewColumn[uint64](1), Age: sqldb.NewColumn(46)}}, users)
+}
+
+func TestPager(t *testing.T) {
+	m := newModel(t)
+	cols := m.Columns()
+	p := m.Query(cols.ID.NE(uint64(2))).Pager(2, cols.ID)
+	var pages [][]uint64
+	for p.Next(ctx) {
+		var ids []uint64
+		for _, u := range p.Page() {
+			ids = append(ids, u.ID.V)
+		}
+		pages = append(pages, ids)
+	}
+	assert.Nil(t, p.Err())
+	assert.Equal(t, [][]uint64{{1, 3}, {4}}, pages)
+}
+
+func TestListWithAggregate(t *testing.T) {
+	m := newModel(t)
+	cols := m.Columns()
+	users, results, total, err := m.Query(cols.ID.NE(uint64(2))).ListWithAggregate(ctx,
+		sqldb.ListOptions{Limit: 1, SortOptions: []sqldb.SortOption{cols.ID.Sort(sqldb.SortOrderAscending)}},
+		sqldb.Aggregate{Func: sqldb.AggregateSum, Column: cols.Age, As: sqldb.ColumnName{Name: "total_age"}},
+		sqldb.Aggregate{Func: sqldb.AggregateCount, As: sqldb.ColumnName{Name: "count"}},
+		sqldb.Aggregate{Func: sqldb.AggregateMax, Column: cols.Name, As: sqldb.ColumnName{Name: "max_name"}},
+	)
+	assert.Nil(t, err, err)
+	assert.Equal(t, []User{*u1}, users)
+	assert.Equal(t, uint64(3), total)
+	assert.Equal(t, map[string]any{"total_age": float64(105), "count": int64(3), "max_name": "William K Turner"}, results)
+}
+
+func TestTruncate(t *testing.T) {
+	m := newModel(t)
+	assert.Nil(t, m.Truncate(ctx))
+	users, err := m.Query().Find(ctx, sqldb.ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Empty(t, users)
+}
+
+func TestQuantifiedQuery(t *testing.T) {
+	m := newModel(t)
+	cols := m.Columns()
+	users, err := m.Query(cols.Age.GtAll([]int{30, 45})).Find(ctx, sqldb.ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Equal(t, []User{*u1, *u2}, users)
+	users, err = m.Query(cols.Age.EqAny([]int{30, 29})).Find(ctx, sqldb.ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Equal(t, []User{*u3, *u4}, users)
+}
+
+func TestFlags(t *testing.T) {
+	m := newModel(t)
+	cols := m.Columns()
+	users, err := m.Query(cols.Age.HasFlag(12)).Find(ctx, sqldb.ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Equal(t, []User{*u1, *u3, *u4}, users)
+
+	_, err = m.Query(cols.ID.EQ(uint64(2))).Update(ctx, cols.Age.SetFlag(2))
+	assert.Nil(t, err, err)
+	_, err = m.Query(cols.ID.EQ(uint64(2))).Update(ctx, cols.Age.ClearFlag(1))
+	assert.Nil(t, err, err)
+	user, err := m.Query(cols.ID.EQ(uint64(2))).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, 50, user.Age.V)
+}
+
+func TestHistogram(t *testing.T) {
+	m := newModel(t)
+	cols := m.Columns()
+
+	buckets := []sqldb.Bucket{{To: 30}, {From: 30, To: 40}, {From: 40}, {From: 100, To: 200}}
+	counts, err := m.Query().Histogram(ctx, cols.Age, buckets)
+	assert.Nil(t, err, err)
+	assert.Equal(t, []sqldb.BucketCount{
+		{Bucket: buckets[0], Count: 1},
+		{Bucket: buckets[1], Count: 1},
+		{Bucket: buckets[2], Count: 2},
+		{Bucket: buckets[3], Count: 0},
+	}, counts)
+
+	counts, err = m.Query(cols.Age.GT(29)).Histogram(ctx, cols.Age, []sqldb.Bucket{{From: 40}, {}})
+	assert.Nil(t, err, err)
+	assert.Equal(t, []uint64{2, 1}, []uint64{counts[0].Count, counts[1].Count})
+}
+
+func TestDeleteInBatches(t *testing.T) {
+	m := newModel(t)
+	cols := m.Columns()
+
+	deleted, err := m.Query(cols.Age.GT(29)).DeleteInBatches(ctx, 2)
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, 3, deleted)
+	users, err := m.Query().Find(ctx, sqldb.ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Len(t, users, 1)
+}
+
+func TestRowValues(t *testing.T) {
+	m := newModel(t)
+	cols := m.Columns()
+	ids := func(users []User) (ids []uint64) {
+		for _, u := range users {
+			ids = append(ids, u.ID.V)
+		}
+		return
+	}
+
+	sorts := []sqldb.SortOption{cols.Age.Sort(sqldb.SortOrderAscending), cols.ID.Sort(sqldb.SortOrderAscending)}
+	users, err := m.Query(sqldb.RowValues(cols.Age, cols.ID).GT(30, 3)).Find(ctx, sqldb.ListOptions{SortOptions: sorts})
+	assert.Nil(t, err, err)
+	assert.Equal(t, []uint64{1, 2}, ids(users))
+
+	users, err = m.Query(sqldb.RowValues(cols.Age, cols.ID).LTE(30, 3)).Find(ctx, sqldb.ListOptions{SortOptions: sorts})
+	assert.Nil(t, err, err)
+	assert.Equal(t, []uint64{4, 3}, ids(users))
+
+	assert.Panics(t, func() { sqldb.RowValues(cols.Age, cols.ID).GT(30) })
+}
+
+func TestSortByValues(t *testing.T) {
+	m := newModel(t)
+	cols := m.Columns()
+
+	ids := []uint64{3, 1, 4}
+	users, err := m.Query().Find(ctx, sqldb.ListOptions{SortOptions: []sqldb.SortOption{cols.ID.SortByValues(ids)}})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{*u3, *u1, *u4, *u2}, users)
+}
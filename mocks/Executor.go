@@ -0,0 +1,154 @@
+package mocks
+
+import (
+	"context"
+	"database/sql"
+	"io"
+
+	"github.com/stretchr/testify/mock"
+
+	"github.com/YLonely/sqldb"
+)
+
+// Executor is a mock of sqldb.Executor.
+type Executor[T any] struct {
+	mock.Mock
+}
+
+var _ sqldb.Executor[struct{}] = (*Executor[struct{}])(nil)
+
+func (m *Executor[T]) Get(ctx context.Context) (T, error) {
+	args := m.Called(ctx)
+	return get[T](args, 0), args.Error(1)
+}
+
+func (m *Executor[T]) List(ctx context.Context, opts sqldb.ListOptions) ([]T, uint64, error) {
+	args := m.Called(ctx, opts)
+	return get[[]T](args, 0), get[uint64](args, 1), args.Error(2)
+}
+
+func (m *Executor[T]) Find(ctx context.Context, opts sqldb.ListOptions) ([]T, error) {
+	args := m.Called(ctx, opts)
+	return get[[]T](args, 0), args.Error(1)
+}
+
+func (m *Executor[T]) ListInto(ctx context.Context, opts sqldb.ListOptions, dest *[]T) (uint64, error) {
+	args := m.Called(ctx, opts, dest)
+	return get[uint64](args, 0), args.Error(1)
+}
+
+func (m *Executor[T]) ListRaw(ctx context.Context, opts sqldb.ListOptions) ([]map[string]any, uint64, error) {
+	args := m.Called(ctx, opts)
+	return get[[]map[string]any](args, 0), get[uint64](args, 1), args.Error(2)
+}
+
+func (m *Executor[T]) Update(ctx context.Context, opts ...sqldb.UpdateOption) (uint64, error) {
+	args := m.Called(ctx, opts)
+	return get[uint64](args, 0), args.Error(1)
+}
+
+func (m *Executor[T]) Delete(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+func (m *Executor[T]) DeleteInBatches(ctx context.Context, batchSize int) (uint64, error) {
+	args := m.Called(ctx, batchSize)
+	return get[uint64](args, 0), args.Error(1)
+}
+
+func (m *Executor[T]) AllowGlobalUpdate() sqldb.Executor[T] {
+	return get[sqldb.Executor[T]](m.Called(), 0)
+}
+
+func (m *Executor[T]) DistinctOn(cols ...sqldb.ColumnNameGetter) sqldb.Executor[T] {
+	return get[sqldb.Executor[T]](m.Called(cols), 0)
+}
+
+func (m *Executor[T]) Preload(associations ...string) sqldb.Executor[T] {
+	return get[sqldb.Executor[T]](m.Called(associations), 0)
+}
+
+func (m *Executor[T]) WithIndexHint(indexName string) sqldb.Executor[T] {
+	return get[sqldb.Executor[T]](m.Called(indexName), 0)
+}
+
+func (m *Executor[T]) Apply(scopes ...sqldb.Scope) sqldb.Executor[T] {
+	return get[sqldb.Executor[T]](m.Called(scopes), 0)
+}
+
+func (m *Executor[T]) MaxBy(ctx context.Context, col sqldb.ColumnNameGetter) (T, error) {
+	args := m.Called(ctx, col)
+	return get[T](args, 0), args.Error(1)
+}
+
+func (m *Executor[T]) MinBy(ctx context.Context, col sqldb.ColumnNameGetter) (T, error) {
+	args := m.Called(ctx, col)
+	return get[T](args, 0), args.Error(1)
+}
+
+func (m *Executor[T]) DescribeFilters() []sqldb.FilterDescriptor {
+	return get[[]sqldb.FilterDescriptor](m.Called(), 0)
+}
+
+func (m *Executor[T]) Stream(ctx context.Context, opts sqldb.ListOptions) (<-chan T, <-chan error) {
+	args := m.Called(ctx, opts)
+	return get[<-chan T](args, 0), get[<-chan error](args, 1)
+}
+
+func (m *Executor[T]) Pager(pageSize uint64, col sqldb.ColumnNameGetter) *sqldb.Pager[T] {
+	return get[*sqldb.Pager[T]](m.Called(pageSize, col), 0)
+}
+
+func (m *Executor[T]) ListWithAggregate(ctx context.Context, opts sqldb.ListOptions, aggs ...sqldb.Aggregate) ([]T, map[string]any, uint64, error) {
+	args := m.Called(ctx, opts, aggs)
+	return get[[]T](args, 0), get[map[string]any](args, 1), get[uint64](args, 2), args.Error(3)
+}
+
+func (m *Executor[T]) DequeueOne(ctx context.Context, extraFilters ...sqldb.FilterOption) (T, error) {
+	args := m.Called(ctx, extraFilters)
+	return get[T](args, 0), args.Error(1)
+}
+
+func (m *Executor[T]) CountBy(ctx context.Context, col sqldb.ColumnNameGetter, extraFilters ...sqldb.FilterOption) (map[any]uint64, error) {
+	args := m.Called(ctx, col, extraFilters)
+	return get[map[any]uint64](args, 0), args.Error(1)
+}
+
+func (m *Executor[T]) DistinctCountBy(ctx context.Context, groupCol, distinctCol sqldb.ColumnNameGetter, extraFilters ...sqldb.FilterOption) (map[any]uint64, error) {
+	args := m.Called(ctx, groupCol, distinctCol, extraFilters)
+	return get[map[any]uint64](args, 0), args.Error(1)
+}
+
+func (m *Executor[T]) Histogram(ctx context.Context, col sqldb.ColumnNameGetter, buckets []sqldb.Bucket) ([]sqldb.BucketCount, error) {
+	args := m.Called(ctx, col, buckets)
+	return get[[]sqldb.BucketCount](args, 0), args.Error(1)
+}
+
+func (m *Executor[T]) Rows(ctx context.Context, opts sqldb.ListOptions) (*sql.Rows, error) {
+	args := m.Called(ctx, opts)
+	return get[*sql.Rows](args, 0), args.Error(1)
+}
+
+func (m *Executor[T]) UpdateFrom(ctx context.Context, other sqldb.Tabler, on []sqldb.OpOption, sets ...sqldb.UpdateOption) (uint64, error) {
+	args := m.Called(ctx, other, on, sets)
+	return get[uint64](args, 0), args.Error(1)
+}
+
+func (m *Executor[T]) Restore(ctx context.Context) (uint64, error) {
+	args := m.Called(ctx)
+	return get[uint64](args, 0), args.Error(1)
+}
+
+func (m *Executor[T]) DeleteUnmatched(ctx context.Context, other sqldb.Tabler, on []sqldb.OpOption, extraFilters ...sqldb.FilterOption) (uint64, error) {
+	args := m.Called(ctx, other, on, extraFilters)
+	return get[uint64](args, 0), args.Error(1)
+}
+
+func (m *Executor[T]) DeleteUsing(ctx context.Context, other sqldb.Tabler, on []sqldb.OpOption, extraFilters ...sqldb.FilterOption) (uint64, error) {
+	args := m.Called(ctx, other, on, extraFilters)
+	return get[uint64](args, 0), args.Error(1)
+}
+
+func (m *Executor[T]) Export(ctx context.Context, opts sqldb.ListOptions, w io.Writer, format sqldb.ExportFormat) error {
+	return m.Called(ctx, opts, w, format).Error(0)
+}
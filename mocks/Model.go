@@ -0,0 +1,94 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+
+	"github.com/YLonely/sqldb"
+)
+
+// Model is a mock of sqldb.Model.
+type Model[T any] struct {
+	mock.Mock
+}
+
+var _ sqldb.Model[struct{}] = (*Model[struct{}])(nil)
+
+func (m *Model[T]) DB(ctx context.Context) *gorm.DB {
+	return get[*gorm.DB](m.Called(ctx), 0)
+}
+
+func (m *Model[T]) WithDB(db *gorm.DB) sqldb.Model[T] {
+	return get[sqldb.Model[T]](m.Called(db), 0)
+}
+
+func (m *Model[T]) EnsureIndexes(ctx context.Context, create bool) error {
+	return m.Called(ctx, create).Error(0)
+}
+
+func (m *Model[T]) Truncate(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+func (m *Model[T]) Table() string {
+	return get[string](m.Called(), 0)
+}
+
+func (m *Model[T]) Columns() T {
+	return get[T](m.Called(), 0)
+}
+
+func (m *Model[T]) ColumnNames() []sqldb.ColumnNameGetter {
+	return get[[]sqldb.ColumnNameGetter](m.Called(), 0)
+}
+
+func (m *Model[T]) ValueOf(entity *T, col sqldb.ColumnNameGetter) (any, error) {
+	args := m.Called(entity, col)
+	return get[any](args, 0), args.Error(1)
+}
+
+func (m *Model[T]) SetValue(entity *T, col sqldb.ColumnNameGetter, v any) error {
+	return m.Called(entity, col, v).Error(0)
+}
+
+func (m *Model[T]) Create(ctx context.Context, entity *T) error {
+	return m.Called(ctx, entity).Error(0)
+}
+
+func (m *Model[T]) CreateOrIgnore(ctx context.Context, entity *T) (bool, error) {
+	args := m.Called(ctx, entity)
+	return get[bool](args, 0), args.Error(1)
+}
+
+func (m *Model[T]) CreateReturningID(ctx context.Context, entity *T) (int64, error) {
+	args := m.Called(ctx, entity)
+	return get[int64](args, 0), args.Error(1)
+}
+
+func (m *Model[T]) Upsert(ctx context.Context, entity *T, conflictColumns, updateColumns []sqldb.ColumnNameGetter, opts ...sqldb.UpsertOption) error {
+	return m.Called(ctx, entity, conflictColumns, updateColumns, opts).Error(0)
+}
+
+func (m *Model[T]) UpsertInBatches(ctx context.Context, entities []*T, conflictColumns, updateColumns []sqldb.ColumnNameGetter, batchSize int, opts ...sqldb.UpsertOption) error {
+	return m.Called(ctx, entities, conflictColumns, updateColumns, batchSize, opts).Error(0)
+}
+
+func (m *Model[T]) UpdateDiff(ctx context.Context, oldEntity, newEntity *T) (uint64, error) {
+	args := m.Called(ctx, oldEntity, newEntity)
+	return get[uint64](args, 0), args.Error(1)
+}
+
+func (m *Model[T]) Marshal(entity T) ([]byte, error) {
+	args := m.Called(entity)
+	return get[[]byte](args, 0), args.Error(1)
+}
+
+func (m *Model[T]) Unmarshal(data []byte, entity *T) error {
+	return m.Called(data, entity).Error(0)
+}
+
+func (m *Model[T]) Query(queries ...sqldb.FilterOption) sqldb.Executor[T] {
+	return get[sqldb.Executor[T]](m.Called(queries), 0)
+}
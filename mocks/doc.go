@@ -0,0 +1,24 @@
+// Package mocks provides mocks of the interfaces in package sqldb based on github.com/stretchr/testify/mock. The mocks
+// are maintained by hand since they are generic, TestMethodSets checks that they have exactly the methods of the
+// interfaces.
+//
+// Variadic arguments are passed to the mocks as a single slice argument, for example:
+//
+//	executor := &mocks.Executor[User]{}
+//	executor.On("Update", mock.Anything, []sqldb.UpdateOption{opt}).Return(uint64(1), nil)
+package mocks
+
+import (
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/mock"
+)
+
+// get returns the i-th return value as type R, the zero value is returned if the value is nil. It panics if the value
+// is of another type, just like the generated mocks do, so that a wrong Return is not silently turned into zero.
+func get[R any](args mock.Arguments, i int) R {
+	v := args.Get(i)
+	if v == nil {
+		return lo.Empty[R]()
+	}
+	return v.(R)
+}
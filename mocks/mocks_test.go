@@ -0,0 +1,38 @@
+package mocks
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/YLonely/sqldb"
+)
+
+// methods returns the names of the methods of t, except the ones promoted from mock.Mock.
+func methods(t reflect.Type) []string {
+	mockType := reflect.TypeOf(&mock.Mock{})
+	var names []string
+	for i := 0; i < t.NumMethod(); i++ {
+		name := t.Method(i).Name
+		if _, ok := mockType.MethodByName(name); !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestMethodSets(t *testing.T) {
+	assert.Equal(t, methods(reflect.TypeOf((*sqldb.Executor[struct{}])(nil)).Elem()), methods(reflect.TypeOf(&Executor[struct{}]{})))
+	assert.Equal(t, methods(reflect.TypeOf((*sqldb.Model[struct{}])(nil)).Elem()), methods(reflect.TypeOf(&Model[struct{}]{})))
+}
+
+func TestGet(t *testing.T) {
+	args := mock.Arguments{nil, uint64(1)}
+	assert.Nil(t, get[[]string](args, 0))
+	assert.Equal(t, uint64(1), get[uint64](args, 1))
+	assert.Panics(t, func() { get[string](args, 1) })
+}
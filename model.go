@@ -1,16 +1,26 @@
 package sqldb
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
+	"sort"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	internalsql "github.com/YLonely/sqldb/internal/sql"
 	"github.com/samber/lo"
 	"github.com/samber/mo"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	gormschema "gorm.io/gorm/schema"
 )
 
@@ -21,6 +31,9 @@ type contextKey int
 
 const (
 	transactionContextKey contextKey = iota
+	primaryReadsContextKey
+	auditUserContextKey
+	scopesContextKey
 )
 
 func WithTransaction(ctx context.Context, tx *gorm.DB) context.Context {
@@ -34,6 +47,46 @@ func TransactionFrom(ctx context.Context) *gorm.DB {
 	return nil
 }
 
+// WithPrimaryReads returns a context which makes models read from the primary db instead of read replicas,
+// it keeps reads after writes consistent regardless of the replication lag.
+func WithPrimaryReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadsContextKey, true)
+}
+
+// PrimaryReadsFrom reports whether ctx forces reads to the primary db.
+func PrimaryReadsFrom(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryReadsContextKey).(bool)
+	return primary
+}
+
+// WithAuditUser returns a context carrying the user who makes the changes, which is written to the audit columns
+// of models created with WithAuditColumns.
+func WithAuditUser(ctx context.Context, user any) context.Context {
+	return context.WithValue(ctx, auditUserContextKey, user)
+}
+
+// AuditUserFrom returns the user carried by ctx, it returns nil if there is none.
+func AuditUserFrom(ctx context.Context) any {
+	return ctx.Value(auditUserContextKey)
+}
+
+// WithScopeIf returns a context which adds opts to the filters of reads if predicate is true, e.g. to only show
+// published rows unless the viewer is staff. Scopes of nested calls are combined. Models skip the options filtering
+// columns of other tables, so that a context can carry the scopes of several models.
+func WithScopeIf(ctx context.Context, predicate bool, opts ...FilterOption) context.Context {
+	if !predicate || len(opts) == 0 {
+		return ctx
+	}
+	scopes := ScopesFrom(ctx)
+	return context.WithValue(ctx, scopesContextKey, append(scopes[:len(scopes):len(scopes)], opts...))
+}
+
+// ScopesFrom returns the filter options added to ctx by WithScopeIf.
+func ScopesFrom(ctx context.Context) []FilterOption {
+	scopes, _ := ctx.Value(scopesContextKey).([]FilterOption)
+	return scopes
+}
+
 // NewTransactionFunc returns a TransactionFunc.
 func NewTransactionFunc(db *gorm.DB) TransactionFunc {
 	return func(ctx context.Context, run func(context.Context) error) error {
@@ -48,35 +101,163 @@ func NewTransactionFunc(db *gorm.DB) TransactionFunc {
 	}
 }
 
+// InTransaction runs fn in a transaction of db, which is committed if fn returns nil and rolled back otherwise.
+// The context passed to fn carries the transaction, so models called with it operate in the transaction.
+// If ctx already carries a transaction, fn runs in a nested one like NewTransactionFunc does.
+func InTransaction(ctx context.Context, db *gorm.DB, fn func(context.Context) error) error {
+	return NewTransactionFunc(db)(ctx, fn)
+}
+
 // Model is an interface defines commonly used methods to manipulate data.
 type Model[T any] interface {
 	// DB returns the db instance.
 	DB(context.Context) *gorm.DB
+	// WithDB returns a copy of the model which operates on db instead of the db it is created with, e.g. a session
+	// of another Postgres schema. Read replicas are not used by the copy, and a transaction carried by the context
+	// still takes precedence.
+	WithDB(db *gorm.DB) Model[T]
 	// Table returns the table name in the database.
 	Table() string
 	// Columns returns a instance of type T,
 	// all fields of type sqldb.Column[U] in the instance are populated with corresponding column name.
 	Columns() T
-	// ColumnNames returns all column names the model has.
+	// ColumnNames returns all column names the model has, in the declaration order of their fields.
 	ColumnNames() []ColumnNameGetter
+	// ValueOf returns the value of the column col in entity, which is the V field of the column.
+	ValueOf(entity *T, col ColumnNameGetter) (any, error)
+	// SetValue sets the value of the column col in entity to v, which is converted to the type of the column.
+	// A nil v sets the zero value, which is NULL for pointer columns.
+	SetValue(entity *T, col ColumnNameGetter, v any) error
+	// EnsureIndexes checks that the indexes declared by the `index` and `uniqueIndex` tags of T exist, missing ones
+	// are created if create is true, otherwise an error naming them is returned, e.g. to verify the schema at startup.
+	EnsureIndexes(ctx context.Context, create bool) error
+	// Truncate removes all rows of the table with `TRUNCATE TABLE`, which also restarts the identity columns on
+	// Postgres. Soft delete is bypassed. SQLite has no TRUNCATE, so rows are deleted by an unconditional DELETE instead.
+	Truncate(ctx context.Context) error
 	// Create creates an new entity of type T.
 	Create(ctx context.Context, entity *T) error
+	// CreateOrIgnore is like Create but silently ignores the conflict of entity with an existing row on a unique
+	// constraint, it reports whether entity is created.
+	CreateOrIgnore(ctx context.Context, entity *T) (bool, error)
+	// CreateReturningID is like Create but returns the id generated by the database for the insert, which is read by
+	// `LAST_INSERT_ID()` on MySQL, `last_insert_rowid()` on SQLite and `lastval()` of the last used sequence on
+	// Postgres. It helps when the primary key is not the auto increment column of T. Other dialects are not supported.
+	CreateReturningID(ctx context.Context, entity *T) (int64, error)
+	// Upsert creates entity, if it conflicts with an existing row on conflictColumns, updateColumns of the row
+	// are updated instead, nothing is updated if updateColumns is empty.
+	Upsert(ctx context.Context, entity *T, conflictColumns, updateColumns []ColumnNameGetter, opts ...UpsertOption) error
+	// UpsertInBatches is like Upsert but creates entities in batches of batchSize.
+	// All batches are created in a single transaction, the first error is returned.
+	UpsertInBatches(ctx context.Context, entities []*T, conflictColumns, updateColumns []ColumnNameGetter, batchSize int, opts ...UpsertOption) error
+	// UpdateDiff updates the row of oldEntity, which is found by its primary key, with the columns of newEntity whose
+	// values differ from oldEntity. Nothing is updated if there is no difference.
+	UpdateDiff(ctx context.Context, oldEntity, newEntity *T) (uint64, error)
+	// Marshal returns the JSON encoding of entity, the keys are decided by the JSONKeyStrategy of the model.
+	Marshal(entity T) ([]byte, error)
+	// Unmarshal parses the JSON encoded data produced by Marshal and stores the result in entity.
+	Unmarshal(data []byte, entity *T) error
 	Query(queries ...FilterOption) Executor[T]
 }
 
 // Executor is an interface wraps operations related to db queries.
 type Executor[T any] interface {
+	// Get returns the first matched entity, it returns gorm.ErrRecordNotFound if nothing matches, for joined models as well.
 	Get(ctx context.Context) (T, error)
 	List(ctx context.Context, opts ListOptions) ([]T, uint64, error)
+	// Find is like List but does not count the total number of matched entities.
+	Find(ctx context.Context, opts ListOptions) ([]T, error)
+	// ListInto is like List but stores the entities in dest, the underlying array of dest is reused if possible.
+	ListInto(ctx context.Context, opts ListOptions, dest *[]T) (uint64, error)
+	// ListRaw is like List but returns the rows as maps keyed by column names, which are the full names for joined
+	// models. Values are returned as the driver scans them without deserialization, except that []byte become strings.
+	ListRaw(ctx context.Context, opts ListOptions) ([]map[string]any, uint64, error)
+	// ListWithAggregate is like List but also computes the aggregates over all entities matching the filters regardless
+	// of pagination, the results are keyed by the aliases of aggregates. Values are returned as the driver scans them,
+	// except that []byte become strings, and aggregates other than COUNT over no entities are nil.
+	ListWithAggregate(ctx context.Context, opts ListOptions, aggs ...Aggregate) ([]T, map[string]any, uint64, error)
 	Update(ctx context.Context, opts ...UpdateOption) (uint64, error)
 	Delete(ctx context.Context) error
+	// DeleteInBatches deletes the matched entities by statements deleting at most batchSize rows each until none
+	// remain, and returns the number of deleted entities. Each batch is committed on its own unless ctx carries
+	// a transaction, so that locks are held shortly. Batches are limited by `DELETE ... LIMIT` on MySQL and by
+	// the subquery `WHERE pk IN (SELECT pk ... LIMIT n)` on other dialects, which requires a single primary key.
+	DeleteInBatches(ctx context.Context, batchSize int) (uint64, error)
+	// Restore clears the soft delete flag of the matched entities, including the deleted ones, and returns the number
+	// of restored entities. It is only supported by models created with WithSoftDeleteFlag.
+	Restore(ctx context.Context) (uint64, error)
+	// MaxBy returns the entity with the max value of col, it returns gorm.ErrRecordNotFound if nothing matches.
+	// NULL values are sorted as the dialect does, e.g. they come first in descending order on Postgres.
+	MaxBy(ctx context.Context, col ColumnNameGetter) (T, error)
+	// MinBy is like MaxBy but returns the entity with the min value of col.
+	MinBy(ctx context.Context, col ColumnNameGetter) (T, error)
+	// WithIndexHint makes queries of the executor use the index, it is an escape hatch for plans the planner gets wrong.
+	// The hint is `FORCE INDEX` on MySQL, `INDEXED BY` on SQLite and an IndexScan comment of pg_hint_plan on Postgres.
+	// Updates and deletes are not hinted, and joined models do not support hints.
+	WithIndexHint(indexName string) Executor[T]
+	// AllowGlobalUpdate allows Update and Delete to affect the whole table when there are no filter options,
+	// which is rejected by models created with WithSafeDestructive.
+	AllowGlobalUpdate() Executor[T]
+	// DistinctOn keeps only the first row of each set of rows where the given columns are equal when listing entities.
+	// It is only supported by Postgres, and the leading sort options must match the given columns. The totals of
+	// listing count the distinct rows, and ListOptions.WindowTotal is not supported with it.
+	DistinctOn(cols ...ColumnNameGetter) Executor[T]
+	// Preload loads the given associations of the listed entities, each association is loaded by a single batched query.
+	// It is not supported by joined models and is ignored by streaming operations such as Export.
+	Preload(associations ...string) Executor[T]
+	// Apply adds the filter options of the scopes to the executor.
+	Apply(scopes ...Scope) Executor[T]
+	// DescribeFilters describes the filter options of the executor, see the function DescribeFilters.
+	DescribeFilters() []FilterDescriptor
+	// Stream sends the listed entities to the returned channel one by one, which is closed when all entities are sent.
+	// The error channel receives at most one error and is closed after the entity channel. Canceling ctx stops the
+	// producer, the consumer should keep receiving until the entity channel is closed.
+	Stream(ctx context.Context, opts ListOptions) (<-chan T, <-chan error)
+	// Pager returns a Pager which reads the entities page by page in ascending order of col with keyset pagination,
+	// col must be unique and not NULL.
+	Pager(pageSize uint64, col ColumnNameGetter) *Pager[T]
+	// DequeueOne locks and returns the first entity matching the filters and extraFilters sorted by the default sort,
+	// rows locked by other transactions are skipped with `FOR UPDATE SKIP LOCKED` on Postgres and MySQL 8, so
+	// concurrent workers get distinct entities. It must be called in a transaction and returns gorm.ErrRecordNotFound
+	// if nothing is available. Other dialects do not lock the row.
+	DequeueOne(ctx context.Context, extraFilters ...FilterOption) (T, error)
+	// CountBy counts the entities matching the filters and extraFilters grouped by the values of col.
+	// The keys are the values returned by the driver, []byte values are converted into strings and NULL into nil.
+	CountBy(ctx context.Context, col ColumnNameGetter, extraFilters ...FilterOption) (map[any]uint64, error)
+	// DistinctCountBy is like CountBy but counts the distinct non-NULL values of distinctCol in each group.
+	DistinctCountBy(ctx context.Context, groupCol, distinctCol ColumnNameGetter, extraFilters ...FilterOption) (map[any]uint64, error)
+	// Histogram counts the entities matching the filters in the half-open ranges of buckets on col, the counts are in
+	// the order of buckets. An entity is counted in the first bucket containing it, values out of all buckets are
+	// not counted.
+	Histogram(ctx context.Context, col ColumnNameGetter, buckets []Bucket) ([]BucketCount, error)
+	// Rows returns the rows of the listed entities for custom scanning, the caller must close the rows.
+	// The statement timeout of the model is not applied since the rows outlive the call.
+	Rows(ctx context.Context, opts ListOptions) (*sql.Rows, error)
+	// UpdateFrom updates the entities matching the filters, joining the table of other with the on conditions.
+	// ColumnRef values of sets may refer to columns of other. Entities are updated like Update does, e.g. soft deleted
+	// rows are skipped, read-only columns are rejected and it is guarded by WithSafeDestructive.
+	UpdateFrom(ctx context.Context, other Tabler, on []OpOption, sets ...UpdateOption) (uint64, error)
+	// DeleteUsing deletes the entities matching the filters which have a row of other matching the on conditions and
+	// extraFilters, i.e. `WHERE EXISTS (SELECT 1 FROM other WHERE on AND extraFilters)`, extraFilters may refer to
+	// columns of both tables. Entities are deleted like Delete does, e.g. soft deleted and guarded by
+	// WithSafeDestructive.
+	DeleteUsing(ctx context.Context, other Tabler, on []OpOption, extraFilters ...FilterOption) (uint64, error)
+	// DeleteUnmatched is like DeleteUsing but deletes the entities which have no row of other matching the on
+	// conditions and extraFilters, i.e. `WHERE NOT EXISTS (...)`, e.g. users without relations.
+	DeleteUnmatched(ctx context.Context, other Tabler, on []OpOption, extraFilters ...FilterOption) (uint64, error)
+	// Export streams the listed entities to w in the given format without loading all of them into memory.
+	Export(ctx context.Context, opts ListOptions, w io.Writer, format ExportFormat) error
 }
 
 // model implements the Model interface.
+// The maps of columns are only used for lookups, anything iterating the columns, such as ColumnNames and
+// iterateColumns, follows the declaration order of their fields so that the generated SQL is stable.
 type model[T any] struct {
 	columns           *T
 	columnSerializers map[string]serializer
 	fieldPathToColumn map[string]ColumnNameGetter
+	columnNames       []ColumnNameGetter
+	readOnlyColumns   map[string]bool
+	softDeleteFlag    ColumnNameGetter
 	tableName         string
 	joined            bool
 	config            modelConfig
@@ -89,17 +270,52 @@ var _ Model[struct{}] = model[struct{}]{}
 type executor[T any] struct {
 	model[T]
 
-	queries []FilterOption
+	queries     []FilterOption
+	distinctOn  []ColumnNameGetter
+	preloads    []string
+	allowGlobal bool
+	indexHint   string
 }
 
+// ErrMissingFilters is returned when a model created with WithSafeDestructive updates or deletes without filter options.
+var ErrMissingFilters = errors.New("updating or deleting without filter options affects the whole table, use AllowGlobalUpdate if it is intended")
+
 var (
 	serializers = map[string]serializer{
 		"json": jsonSerializer{},
+		"gzip": gzipSerializer{},
 	}
 )
 
 type modelConfig struct {
-	dbInitialFunc func(*gorm.DB) *gorm.DB
+	dbInitialFunc       func(*gorm.DB) *gorm.DB
+	defaultSort         []SortOption
+	jsonKeys            JSONKeyStrategy
+	timeInUTC           bool
+	timeout             time.Duration
+	collation           string
+	qualifiedColumns    bool
+	valuesListThreshold int
+	safeDestructive     bool
+	replicas            []*gorm.DB
+	nextReplica         *uint64
+	tableAlias          string
+	schema              string
+	asOfSystemTime      bool
+	createdBy           string
+	updatedBy           string
+	columnNamer         ColumnNamer
+	errorMapper         func(error) error
+	softDeleteFlag      string
+	// baseDB returns the db which a joined model starts from, it is the db of the left model for the context of
+	// each call, so that the transaction and the deadline of the call are respected.
+	baseDB func(ctx context.Context) *gorm.DB
+	// filters are the filters of every query of the model.
+	filters []FilterOption
+	// joinedTables are the names or aliases of the left and right tables of a joined model.
+	joinedTables []string
+	// selectAliases map the full names of the selected columns of a joined model to their aliases set by As.
+	selectAliases map[string]string
 }
 
 type ModelOption func(*modelConfig)
@@ -110,12 +326,148 @@ func WithDBInitialFunc(initial func(*gorm.DB) *gorm.DB) ModelOption {
 	}
 }
 
+// WithDefaultSort sets the sort options used by List and Find when ListOptions.SortOptions is empty.
+func WithDefaultSort(opts ...SortOption) ModelOption {
+	return func(c *modelConfig) {
+		c.defaultSort = opts
+	}
+}
+
+// WithTimeInUTC converts the values of time.Time columns to UTC after they are scanned from the database.
+func WithTimeInUTC() ModelOption {
+	return func(c *modelConfig) {
+		c.timeInUTC = true
+	}
+}
+
+// WithStatementTimeout limits the time each operation of the model can take.
+// The timeout is enforced through the deadline of the context passed to the database driver, which cancels the running
+// statement for all dialects supporting context cancellation. An earlier deadline of the caller's context still takes effect.
+func WithStatementTimeout(d time.Duration) ModelOption {
+	return func(c *modelConfig) {
+		c.timeout = d
+	}
+}
+
+// WithCaseSensitiveCollation sets the collation used by case-sensitive fuzzy queries, it defaults to utf8mb4_bin on MySQL.
+// Postgres matches LIKE case-sensitively unless the column has a nondeterministic collation, so no collation is applied
+// by default. SQLite ignores collations in LIKE, hence case-sensitive fuzzy queries use instr() there and the collation
+// is not used.
+func WithCaseSensitiveCollation(collation string) ModelOption {
+	return func(c *modelConfig) {
+		c.collation = collation
+	}
+}
+
+// WithQualifiedColumns makes the model qualify column names with the table name in filters and sorts, e.g. users.id,
+// which avoids ambiguity when the query references other tables. Columns of joined models are always qualified.
+func WithQualifiedColumns() ModelOption {
+	return func(c *modelConfig) {
+		c.qualifiedColumns = true
+	}
+}
+
+// WithValuesListIn makes In and NotIn with more than threshold values compile into `IN (VALUES (?),(?),...)`
+// instead of a plain IN list, planners like the one of Postgres handle a large VALUES list as a join which performs
+// better than a huge IN list. MySQL requires 8.0.19 or later for the VALUES statement.
+func WithValuesListIn(threshold int) ModelOption {
+	return func(c *modelConfig) {
+		c.valuesListThreshold = threshold
+	}
+}
+
+// WithSafeDestructive makes Update and Delete return ErrMissingFilters if there are no filter options,
+// unless AllowGlobalUpdate is called on the executor.
+func WithSafeDestructive() ModelOption {
+	return func(c *modelConfig) {
+		c.safeDestructive = true
+	}
+}
+
+// WithReadReplicas makes the model read from the replicas in turn, writes and reads in transactions or
+// in contexts returned by WithPrimaryReads go to the primary db.
+func WithReadReplicas(replicas ...*gorm.DB) ModelOption {
+	return func(c *modelConfig) {
+		c.replicas = replicas
+		c.nextReplica = new(uint64)
+	}
+}
+
+// WithTableAlias makes the model refer to its table by alias, columns are qualified with the alias and the table
+// is aliased in queries and joins. It allows joining a table to itself with the two models having different aliases.
+func WithTableAlias(alias string) ModelOption {
+	return func(c *modelConfig) {
+		c.tableAlias = alias
+	}
+}
+
+// WithSchema makes the table of the model live in the schema, Table returns the name qualified with the schema,
+// e.g. tenant.users, which is used in queries, joins and qualified column names.
+func WithSchema(name string) ModelOption {
+	return func(c *modelConfig) {
+		c.schema = name
+	}
+}
+
+// WithAsOfSystemTime declares that the database supports `AS OF SYSTEM TIME` like CockroachDB does, which makes
+// ListOptions.AsOf take effect. CockroachDB is driven by the postgres dialect, so it can not be detected by the dialect name.
+func WithAsOfSystemTime() ModelOption {
+	return func(c *modelConfig) {
+		c.asOfSystemTime = true
+	}
+}
+
+// WithAuditColumns names the columns which store the user carried by the context of WithAuditUser, an empty name
+// disables the column. Create and Upsert set both columns of entities, Update sets the updatedBy column unless
+// it is updated explicitly. Nothing is set if the context carries no user.
+func WithAuditColumns(createdBy, updatedBy string) ModelOption {
+	return func(c *modelConfig) {
+		c.createdBy = createdBy
+		c.updatedBy = updatedBy
+	}
+}
+
+// ColumnNamer names the column of the field sf, parents are the structs enclosing sf from the outermost one.
+type ColumnNamer func(sf reflect.StructField, parents ...reflect.StructField) string
+
+// WithColumnNamer names the columns of fields without a `column` tag by namer instead of the NamingStrategy of db,
+// the `embeddedPrefix` of parents is still prepended. gorm maps fields with its own NamingStrategy when it creates
+// and scans entities, so namer must produce the names gorm resolves, e.g. when the NamingStrategy names columns
+// by the table or the model is created by a db which is configured differently.
+func WithColumnNamer(namer ColumnNamer) ModelOption {
+	return func(c *modelConfig) {
+		c.columnNamer = namer
+	}
+}
+
+// WithErrorMapper maps the errors returned by the operations of the model with mapper, e.g. to translate the unique
+// violation on an index into a domain error. It applies to every operation which returns an error, including the
+// errors sent by Stream, each error is mapped once and mapper is never called with nil.
+func WithErrorMapper(mapper func(error) error) ModelOption {
+	return func(c *modelConfig) {
+		c.errorMapper = mapper
+	}
+}
+
+// WithSoftDeleteFlag makes the boolean column col the soft delete marker of the model, for schemas which flag
+// deleted rows rather than using gorm.DeletedAt. Delete sets the flag instead of removing rows, queries, updates and
+// subqueries skip flagged rows, and Restore clears the flag. Rows with a NULL flag are skipped as well.
+// It is ignored by joined models, and col can be built by NewColumnName since the columns of the model do not
+// exist yet, e.g. WithSoftDeleteFlag(NewColumnName("is_deleted")).
+func WithSoftDeleteFlag(col ColumnNameGetter) ModelOption {
+	return func(c *modelConfig) {
+		c.softDeleteFlag = col.GetColumnName().Name
+	}
+}
+
 // NewModel returns a new Model.
 func NewModel[T any](db *gorm.DB, opts ...ModelOption) Model[T] {
 	var (
 		m                 = new(T)
 		serializers       = map[string]serializer{}
 		fieldPathToColumn = map[string]ColumnNameGetter{}
+		columnNames       []ColumnNameGetter
+		readOnlyColumns   = map[string]bool{}
 		tableName         string
 		leftTableName     string
 		rightTableName    string
@@ -134,10 +486,16 @@ func NewModel[T any](db *gorm.DB, opts ...ModelOption) Model[T] {
 		tableName = joinResult._tableName()
 		leftTableName = db.NamingStrategy.TableName(reflect.TypeOf(joinResult._left()).Name())
 		rightTableName = db.NamingStrategy.TableName(reflect.TypeOf(joinResult._right()).Name())
+		if len(cfg.joinedTables) == 2 {
+			leftTableName, rightTableName = cfg.joinedTables[0], cfg.joinedTables[1]
+		}
 	} else {
 		tableName = db.NamingStrategy.TableName(rt.Name())
+		if cfg.schema != "" {
+			tableName = cfg.schema + "." + tableName
+		}
 	}
-	if err := iterateFields(m, func(fieldAddr reflect.Value, path []reflect.StructField) (bool, error) {
+	if err := iterateFields(m, true, func(fieldAddr reflect.Value, path []reflect.StructField) (bool, error) {
 		var (
 			fieldInterface = fieldAddr.Interface()
 			fieldNames     = lo.Map(path, func(sf reflect.StructField, _ int) string { return sf.Name })
@@ -152,36 +510,80 @@ func NewModel[T any](db *gorm.DB, opts ...ModelOption) Model[T] {
 		}
 
 		if setter, ok := fieldInterface.(columnNameSetter); ok {
-			name, s := parseColumn(db, path)
+			name, s := parseColumn(db, cfg.columnNamer, path)
 			if joined {
 				setter.setColumnName("", fmt.Sprintf("%s.%s", table, name))
 			} else {
-				setter.setColumnName(table, name)
+				setter.setColumnName(lo.Ternary(cfg.tableAlias != "", cfg.tableAlias, table), name)
 			}
 			cg := fieldInterface.(ColumnNameGetter)
+			// serializers are keyed by String() of column names, which are the full names in joined models,
+			// so that serialized columns with the same name on both sides do not collide.
 			if s != nil {
 				serializers[cg.GetColumnName().String()] = s
 			}
 			fieldPathToColumn[strings.Join(fieldNames, ".")] = cg
+			columnNames = append(columnNames, cg)
+			if !updatable(path[len(path)-1]) {
+				readOnlyColumns[cg.GetColumnName().String()] = true
+			}
 			return false, nil
 		}
 		return true, nil
 	}); err != nil {
 		panic(err)
 	}
+	var softDeleteFlag ColumnNameGetter
+	if cfg.softDeleteFlag != "" && !joined {
+		flag, found := lo.Find(columnNames, func(cg ColumnNameGetter) bool { return cg.GetColumnName().Name == cfg.softDeleteFlag })
+		if !found {
+			panic(fmt.Errorf("the soft delete flag %s is not a column of %s", cfg.softDeleteFlag, tableName))
+		}
+		softDeleteFlag = flag
+	}
 
 	return model[T]{
 		columns:           m,
 		columnSerializers: serializers,
 		db:                db,
 		fieldPathToColumn: fieldPathToColumn,
+		columnNames:       columnNames,
+		readOnlyColumns:   readOnlyColumns,
+		softDeleteFlag:    softDeleteFlag,
 		tableName:         tableName,
 		joined:            joined,
 		config:            cfg,
 	}
 }
 
-func parseColumn(db *gorm.DB, path []reflect.StructField) (string, serializer) {
+// updatable reports whether gorm updates the column of sf following its field permission tags,
+// `->` makes the field read-only unless `<-` grants the update permission.
+func updatable(sf reflect.StructField) bool {
+	tagSettings := gormschema.ParseTagSetting(sf.Tag.Get("gorm"), ";")
+	if v, ok := tagSettings["<-"]; ok {
+		return v == "<-" || strings.Contains(v, "update")
+	}
+	_, readOnly := tagSettings["->"]
+	return !readOnly
+}
+
+// checkWritable returns an error if any of cols is read-only.
+func (m model[T]) checkWritable(cols []ColumnNameGetter) error {
+	for _, col := range cols {
+		if name := col.GetColumnName().String(); m.readOnlyColumns[name] {
+			return fmt.Errorf("the column %s is read-only", name)
+		}
+	}
+	return nil
+}
+
+// parseColumn returns the column name and the serializer of the field at the end of path.
+// The column name follows the rules of gorm: the `column` tag wins over namer and the naming strategy, and the
+// `embeddedPrefix` of a parent struct is prepended only if the parent is anonymous or tagged with `embedded`,
+// prefixes of nested parents are concatenated from the outermost one. Structs that are neither anonymous
+// nor tagged with `embedded` are walked as well and their `embeddedPrefix` is ignored, note that gorm itself
+// rejects such structs unless they are relations.
+func parseColumn(db *gorm.DB, namer ColumnNamer, path []reflect.StructField) (string, serializer) {
 	var (
 		l              = len(path)
 		sf, parents    = path[l-1], path[:l-1]
@@ -191,6 +593,9 @@ func parseColumn(db *gorm.DB, path []reflect.StructField) (string, serializer) {
 		serializer     serializer
 		prefix         string
 	)
+	if column == "" && namer != nil {
+		column = namer(sf, parents...)
+	}
 	if column == "" {
 		column = db.NamingStrategy.ColumnName("", sf.Name)
 	}
@@ -204,240 +609,1806 @@ func parseColumn(db *gorm.DB, path []reflect.StructField) (string, serializer) {
 	column = prefix + column
 
 	if serializerName != "" {
-		if s, exist := serializers[serializerName]; exist {
-			serializer = s
-		} else {
-			panic(fmt.Errorf("unsupported serializer %s", serializerName))
-		}
+		serializer = lookupSerializer(serializerName)
 	}
 	return column, serializer
 }
 
+// lookupSerializer returns the serializer of name, which is a single serializer or a chain of serializers separated
+// by commas, such as `json,gzip`. Serializers unknown to gorm, including chains, are registered to gorm on their first
+// lookup, so that gorm reads and writes the columns with them as well.
+func lookupSerializer(name string) serializer {
+	names := strings.Split(name, ",")
+	chain := make(serializerChain, 0, len(names))
+	for _, n := range names {
+		s, exist := serializers[strings.TrimSpace(n)]
+		if !exist {
+			panic(fmt.Errorf("unsupported serializer %s", n))
+		}
+		chain = append(chain, s)
+	}
+	var s serializer = chain
+	if len(chain) == 1 {
+		s = chain[0]
+	}
+	if _, exist := gormschema.GetSerializer(name); !exist {
+		gormschema.RegisterSerializer(name, gormSerializer{s})
+	}
+	return s
+}
+
 func (m model[T]) DB(ctx context.Context) *gorm.DB {
 	var db *gorm.DB
-	if tx := TransactionFrom(ctx); tx != nil {
+	if m.config.baseDB != nil {
+		db = m.config.baseDB(ctx)
+	} else if tx := TransactionFrom(ctx); tx != nil {
 		db = tx.WithContext(ctx)
 	} else {
 		db = m.db.WithContext(ctx)
 	}
+	return m.initialize(db)
+}
+
+// initialize applies the schema and the initial function of the model to db.
+func (m model[T]) initialize(db *gorm.DB) *gorm.DB {
+	if m.config.schema != "" && !m.joined {
+		// the INSERT clause of some dialects like SQLite ignores the table expression, so the table is given explicitly.
+		db = db.Table(m.tableExpr()).Clauses(clause.Insert{Table: clause.Table{Name: m.tableName}})
+	}
 	if m.config.dbInitialFunc != nil {
 		db = m.config.dbInitialFunc(db)
 	}
 	return db
 }
 
-func (m model[T]) Table() string {
-	return m.tableName
+func (m model[T]) WithDB(db *gorm.DB) Model[T] {
+	m.db = db
+	m.config.replicas = nil
+	m.config.baseDB = nil
+	return m
 }
 
-func (m model[T]) ColumnNames() []ColumnNameGetter {
-	return lo.Values(m.fieldPathToColumn)
+func (m model[T]) EnsureIndexes(ctx context.Context, create bool) (err error) {
+	defer func() { err = m.mapError(err) }()
+	if m.joined {
+		return errors.New("EnsureIndexes is not supported by joined models")
+	}
+	db := m.DB(ctx)
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return err
+	}
+	// indexes are visited in the order of their names so that they are created by the same statements every time.
+	names := lo.Keys(stmt.Schema.ParseIndexes())
+	sort.Strings(names)
+	migrator := db.Migrator()
+	var missing []string
+	for _, name := range names {
+		if migrator.HasIndex(new(T), name) {
+			continue
+		}
+		if !create {
+			missing = append(missing, name)
+		} else if err := migrator.CreateIndex(new(T), name); err != nil {
+			return fmt.Errorf("failed to create the index %s: %w", name, err)
+		}
+	}
+	if len(missing) != 0 {
+		return fmt.Errorf("missing indexes of the table %s: %s", m.tableName, strings.Join(missing, ", "))
+	}
+	return nil
 }
 
-func (m model[T]) Columns() T {
-	return *m.columns
+func (m model[T]) Truncate(ctx context.Context) (err error) {
+	defer func() { err = m.mapError(err) }()
+	if m.joined {
+		return errors.New("Truncate is not supported by joined models")
+	}
+	db := m.DB(ctx)
+	table := clause.Table{Name: m.tableName}
+	switch db.Dialector.Name() {
+	case "postgres":
+		return db.Exec("TRUNCATE TABLE ? RESTART IDENTITY", table).Error
+	case "sqlite":
+		return db.Exec("DELETE FROM ?", table).Error
+	default:
+		return db.Exec("TRUNCATE TABLE ?", table).Error
+	}
 }
 
-func (m model[T]) Create(ctx context.Context, entity *T) error {
-	return m.DB(ctx).Create(entity).Error
+// mapError maps err with the error mapper of the model if there is one.
+func (m model[T]) mapError(err error) error {
+	if err == nil || m.config.errorMapper == nil {
+		return err
+	}
+	return m.config.errorMapper(err)
 }
 
-func (m model[T]) Query(queries ...FilterOption) Executor[T] {
-	return executor[T]{
-		model:   m,
-		queries: queries,
+// tableRef returns the name which columns of the table are qualified with, which is the alias if there is one.
+func (m model[T]) tableRef() string {
+	return lo.Ternary(m.config.tableAlias != "", m.config.tableAlias, m.tableName)
+}
+
+// tableExpr returns the table expression used in FROM and JOIN clauses.
+func (m model[T]) tableExpr() string {
+	return lo.Ternary(m.config.tableAlias != "", fmt.Sprintf("%s AS %s", m.tableName, m.config.tableAlias), m.tableName)
+}
+
+// readDB is like DB but returns a read replica if there is one and ctx does not require the primary db.
+func (m model[T]) readDB(ctx context.Context) *gorm.DB {
+	if len(m.config.replicas) == 0 || TransactionFrom(ctx) != nil || PrimaryReadsFrom(ctx) {
+		return m.DB(ctx)
 	}
+	next := atomic.AddUint64(m.config.nextReplica, 1)
+	return m.initialize(m.config.replicas[next%uint64(len(m.config.replicas))].WithContext(ctx))
 }
 
-func (e executor[T]) Update(ctx context.Context, opts ...UpdateOption) (uint64, error) {
-	if len(opts) == 0 {
-		return 0, errors.New("empty options")
+// withTimeout returns a context that is canceled when the statement timeout of the model expires.
+func (m model[T]) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if m.config.timeout <= 0 {
+		return ctx, func() {}
 	}
-	updateMap := map[string]any{}
-	for _, opt := range opts {
-		column := getColumnName(e.joined, opt)
-		v, err := e.serialize(ctx, column, opt.GetValue())
-		if err != nil {
-			return 0, err
+	return context.WithTimeout(ctx, m.config.timeout)
+}
+
+func (m model[T]) Table() string {
+	return m.tableName
+}
+
+func (m model[T]) ColumnNames() []ColumnNameGetter {
+	return append([]ColumnNameGetter(nil), m.columnNames...)
+}
+
+func (m model[T]) ValueOf(entity *T, col ColumnNameGetter) (any, error) {
+	fieldAddr, err := m.columnField(entity, col)
+	if err != nil {
+		return nil, err
+	}
+	return columnValue(fieldAddr), nil
+}
+
+func (m model[T]) SetValue(entity *T, col ColumnNameGetter, v any) error {
+	fieldAddr, err := m.columnField(entity, col)
+	if err != nil {
+		return err
+	}
+	if !fieldAddr.IsValid() {
+		if v == nil {
+			// the column behind a nil embedded pointer is already NULL.
+			return nil
+		}
+		if fieldAddr, err = m.allocColumn(entity, col); err != nil {
+			return err
 		}
-		updateMap[column] = v
 	}
-	h := newApplyHelper(e.DB(ctx), e.joined, e.serialize).applyFilterOptions(ctx, e.queries)
-	if h.Result().IsError() {
-		return 0, h.Result().Error()
+	if err := setColumnValue(fieldAddr, v); err != nil {
+		return fmt.Errorf("failed to set the column %s: %w", col.GetColumnName(), err)
 	}
-	updated := h.Result().MustGet().Model(new(T)).Updates(updateMap)
-	return uint64(updated.RowsAffected), updated.Error
+	return nil
 }
 
-func (e executor[T]) Delete(ctx context.Context) error {
-	h := newApplyHelper(e.DB(ctx), e.joined, e.serialize).applyFilterOptions(ctx, e.queries)
-	if h.Result().IsError() {
-		return h.Result().Error()
+// columnField returns the address of the field of the column col in entity, which is invalid if the column is behind
+// a nil embedded pointer.
+func (m model[T]) columnField(entity *T, col ColumnNameGetter) (reflect.Value, error) {
+	var (
+		name  = col.GetColumnName().String()
+		field reflect.Value
+		found bool
+	)
+	if err := m.iterateColumns(entity, func(column ColumnNameGetter, fieldAddr reflect.Value, _ reflect.StructField) error {
+		if !found && column.GetColumnName().String() == name {
+			field, found = fieldAddr, true
+		}
+		return nil
+	}); err != nil {
+		return reflect.Value{}, err
+	}
+	if !found {
+		return reflect.Value{}, fmt.Errorf("the column %s does not belong to the table %s", name, m.tableName)
 	}
-	return h.Result().MustGet().Delete(new(T)).Error
+	return field, nil
+}
+
+func (m model[T]) Columns() T {
+	return *m.columns
 }
 
-func (e executor[T]) Get(ctx context.Context) (T, error) {
-	h := newApplyHelper(lo.TernaryF(e.joined,
-		func() *gorm.DB { return e.DB(ctx) },
-		func() *gorm.DB { return e.DB(ctx).Model(new(T)) },
-	), e.joined, e.serialize).applyFilterOptions(ctx, e.queries)
-	if h.Result().IsError() {
-		return lo.Empty[T](), h.Result().Error()
+func (m model[T]) Create(ctx context.Context, entity *T) (err error) {
+	defer func() { err = m.mapError(err) }()
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+	if err := m.setAuditUser(ctx, entity); err != nil {
+		return err
 	}
-	db := h.Result().MustGet()
-	if e.joined {
-		var values map[string]any
-		if err := db.Take(&values).Error; err != nil {
-			return lo.Empty[T](), err
-		}
-		return e.scan(ctx, values)
+	return m.DB(ctx).Create(entity).Error
+}
+
+func (m model[T]) CreateOrIgnore(ctx context.Context, entity *T) (_ bool, err error) {
+	defer func() { err = m.mapError(err) }()
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+	if err := m.setAuditUser(ctx, entity); err != nil {
+		return false, err
 	}
-	var entity T
-	return entity, db.First(&entity).Error
+	created := m.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(entity)
+	return created.RowsAffected > 0, created.Error
 }
 
-func (e executor[T]) List(ctx context.Context, opts ListOptions) (entities []T, total uint64, err error) {
-	var t int64
-	h := newApplyHelper(lo.TernaryF(e.joined,
-		func() *gorm.DB { return e.DB(ctx) },
-		func() *gorm.DB { return e.DB(ctx).Model(new(T)) },
-	), e.joined, e.serialize).applyFilterOptions(ctx, e.queries)
-	if h.Result().IsError() {
-		err = h.Result().Error()
-		return
+func (m model[T]) CreateReturningID(ctx context.Context, entity *T) (id int64, err error) {
+	defer func() { err = m.mapError(err) }()
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+	var query string
+	switch name := m.db.Dialector.Name(); name {
+	case "mysql":
+		query = "SELECT LAST_INSERT_ID()"
+	case "sqlite":
+		query = "SELECT last_insert_rowid()"
+	case "postgres":
+		query = "SELECT lastval()"
+	default:
+		return 0, fmt.Errorf("CreateReturningID is not supported by the dialect %s", name)
 	}
-	db := h.Result().MustGet()
-	if err = db.Count(&t).Error; err != nil {
-		return
+	if err := m.setAuditUser(ctx, entity); err != nil {
+		return 0, err
 	}
-	total = uint64(t)
-	if opts.Limit != 0 {
-		db = db.Limit(int(opts.Limit))
+	// the id is per connection, so it is read in the same transaction as the insert.
+	err = InTransaction(ctx, m.db, func(ctx context.Context) error {
+		if err := m.DB(ctx).Create(entity).Error; err != nil {
+			return err
+		}
+		return TransactionFrom(ctx).WithContext(ctx).Raw(query).Scan(&id).Error
+	})
+	return id, err
+}
+
+func (m model[T]) Upsert(ctx context.Context, entity *T, conflictColumns, updateColumns []ColumnNameGetter, opts ...UpsertOption) (err error) {
+	defer func() { err = m.mapError(err) }()
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+	if err := m.checkWritable(updateColumns); err != nil {
+		return err
 	}
-	if opts.Offset != 0 {
-		db = db.Offset(int(opts.Offset))
+	if err := m.setAuditUser(ctx, entity); err != nil {
+		return err
 	}
-
-	for _, opt := range opts.SortOptions {
-		db = db.Order(fmt.Sprintf("%s %s", getColumnName(e.joined, opt), opt.GetSortOrder()))
+	db := m.DB(ctx)
+	oc, err := m.onConflict(db, conflictColumns, updateColumns, opts)
+	if err != nil {
+		return err
 	}
+	return db.Clauses(oc).Create(entity).Error
+}
 
-	if e.joined {
-		var valuesList []map[string]any
-		if err = db.Find(&valuesList).Error; err != nil {
-			return
+func (m model[T]) UpsertInBatches(ctx context.Context, entities []*T, conflictColumns, updateColumns []ColumnNameGetter, batchSize int, opts ...UpsertOption) (err error) {
+	defer func() { err = m.mapError(err) }()
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+	if err := m.checkWritable(updateColumns); err != nil {
+		return err
+	}
+	if len(entities) == 0 {
+		return nil
+	}
+	for _, entity := range entities {
+		if err := m.setAuditUser(ctx, entity); err != nil {
+			return err
 		}
-		entities, err = MapErr(valuesList, func(values map[string]any, _ int) (T, error) {
-			return e.scan(ctx, values)
-		})
-		return
 	}
-	err = db.Find(&entities).Error
-	return
+	db := m.DB(ctx)
+	oc, err := m.onConflict(db, conflictColumns, updateColumns, opts)
+	if err != nil {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		return tx.Clauses(oc).CreateInBatches(entities, batchSize).Error
+	})
 }
 
-func (e executor[T]) serialize(ctx context.Context, column string, v any) (any, error) {
-	value := v
-	if s, exist := e.columnSerializers[column]; exist {
-		v, err := s.value(ctx, v)
-		if err != nil {
-			return nil, fmt.Errorf("failed to serialize the value of the column %s: %w", column, err)
-		}
-		value = v
+func (m model[T]) UpdateDiff(ctx context.Context, oldEntity, newEntity *T) (uint64, error) {
+	filters, updates, err := m.diff(ctx, oldEntity, newEntity)
+	if err != nil {
+		return 0, m.mapError(err)
 	}
-	return value, nil
+	if len(updates) == 0 {
+		return 0, nil
+	}
+	// errors of Update are already mapped.
+	return m.Query(filters...).Update(ctx, updates...)
 }
 
-func (e executor[T]) scan(ctx context.Context, values map[string]any) (T, error) {
-	target := *e.columns
-	if err := iterateFields(&target, func(fieldAddr reflect.Value, path []reflect.StructField) (bool, error) {
-		fieldPath := strings.Join(lo.Map(path, func(sf reflect.StructField, _ int) string { return sf.Name }), ".")
-		if cg, exist := e.fieldPathToColumn[fieldPath]; exist {
-			columnName := cg.GetColumnName().String()
-			v := values[columnName]
-			if v == nil {
-				return false, nil
-			}
+// diff returns the filters on the primary keys of oldEntity and the updates of the columns which differ in newEntity.
+func (m model[T]) diff(ctx context.Context, oldEntity, newEntity *T) ([]FilterOption, []UpdateOption, error) {
+	if m.joined {
+		return nil, nil, errors.New("UpdateDiff is not supported by joined models")
+	}
+	stmt := &gorm.Statement{DB: m.db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, nil, err
+	}
+	if len(stmt.Schema.PrimaryFieldDBNames) == 0 {
+		return nil, nil, fmt.Errorf("%s has no primary key", m.tableName)
+	}
+	newValues := map[string]any{}
+	if err := m.iterateColumns(newEntity, func(column ColumnNameGetter, fieldAddr reflect.Value, _ reflect.StructField) error {
+		newValues[column.GetColumnName().String()] = columnValue(fieldAddr)
+		return nil
+	}); err != nil {
+		return nil, nil, err
+	}
+	// primary keys are read by gorm, so that plain fields such as the ID of an embedded gorm.Model find the row as well.
+	filters := make([]FilterOption, 0, len(stmt.Schema.PrimaryFields))
+	for _, pk := range stmt.Schema.PrimaryFields {
+		v, _ := pk.ValueOf(ctx, reflect.ValueOf(oldEntity).Elem())
+		if valuer, ok := v.(driver.Valuer); ok {
 			var err error
-			if s, exist := e.columnSerializers[columnName]; exist {
-				err = s.scan(ctx, fieldAddr.Interface(), v)
-			} else {
-				err = fieldAddr.Interface().(interface{ Scan(any) error }).Scan(v)
-			}
-			if err != nil {
-				return false, fmt.Errorf("failed to scan value %v into field %s: %w", v, fieldPath, err)
+			if v, err = valuer.Value(); err != nil {
+				return nil, nil, fmt.Errorf("failed to read the primary key %s: %w", pk.DBName, err)
 			}
-			return false, nil
 		}
-		return true, nil
+		column, found := lo.Find(m.columnNames, func(cg ColumnNameGetter) bool { return cg.GetColumnName().String() == pk.DBName })
+		if !found {
+			column = NewColumnName(pk.DBName)
+		}
+		filters = append(filters, NewOpQueryOption(column.GetColumnName(), OpEq, v))
+	}
+	// without a filter on every primary key the update would hit other rows.
+	if len(filters) == 0 || len(filters) != len(stmt.Schema.PrimaryFieldDBNames) {
+		return nil, nil, fmt.Errorf("the primary keys of %s are not all found in the entity", m.tableName)
+	}
+	var updates []UpdateOption
+	if err := m.iterateColumns(oldEntity, func(column ColumnNameGetter, fieldAddr reflect.Value, _ reflect.StructField) error {
+		name, v := column.GetColumnName().String(), columnValue(fieldAddr)
+		if !lo.Contains(stmt.Schema.PrimaryFieldDBNames, name) && !m.readOnlyColumns[name] && !reflect.DeepEqual(v, newValues[name]) {
+			updates = append(updates, NewUpdateOption(column.GetColumnName(), newValues[name]))
+		}
+		return nil
 	}); err != nil {
-		return lo.Empty[T](), err
+		return nil, nil, err
 	}
-	return target, nil
+	return filters, updates, nil
 }
 
-type applyHelper struct {
-	db        mo.Result[*gorm.DB]
-	serialize func(context.Context, string, any) (any, error)
-	joined    bool
+// setAuditUser sets the audit columns of entity to the user carried by ctx.
+func (m model[T]) setAuditUser(ctx context.Context, entity *T) error {
+	user := AuditUserFrom(ctx)
+	if user == nil || m.config.createdBy == "" && m.config.updatedBy == "" {
+		return nil
+	}
+	return m.iterateColumns(entity, func(column ColumnNameGetter, fieldAddr reflect.Value, _ reflect.StructField) error {
+		if name := column.GetColumnName().String(); name == m.config.createdBy || name == m.config.updatedBy {
+			if !fieldAddr.IsValid() {
+				var err error
+				if fieldAddr, err = m.allocColumn(entity, column); err != nil {
+					return err
+				}
+			}
+			return setColumnValue(fieldAddr, user)
+		}
+		return nil
+	})
 }
 
-func newApplyHelper(db *gorm.DB, joined bool, s func(context.Context, string, any) (any, error)) *applyHelper {
-	return &applyHelper{db: mo.Ok(db), serialize: s, joined: joined}
-}
+// UpsertOption configures the ON CONFLICT clause of upserts.
+type UpsertOption func(*upsertConfig)
 
-func (h *applyHelper) Result() mo.Result[*gorm.DB] {
-	return h.db
+type upsertConfig struct {
+	indexPredicate string
+	conditions     []upsertCondition
 }
 
-func (h *applyHelper) applyFilterOptions(ctx context.Context, opts []FilterOption) *applyHelper {
-	filterOpts := parseFilterOptions(opts)
-	return h.applyOpQueryOptions(ctx, filterOpts.opQueryOptions).
-		applyRangeQueryOptions(ctx, filterOpts.rangeQueryOptions).
-		applyFuzzyQueryOptions(ctx, filterOpts.fuzzyQueryOptions)
+type upsertCondition struct {
+	column ColumnNameGetter
+	op     QueryOp
 }
 
-func (h *applyHelper) applyOpQueryOptions(ctx context.Context, opts []OpQueryOption) *applyHelper {
-	if len(opts) == 0 {
-		return h
+// WithIndexPredicate sets the predicate of a partial unique index which is the conflict target, for example
+// `deleted_at IS NULL`. Postgres and SQLite require it to infer a partial index, MySQL does not support it.
+func WithIndexPredicate(predicate string) UpsertOption {
+	return func(c *upsertConfig) {
+		c.indexPredicate = predicate
 	}
-	query := strings.Join(lo.Map(opts, func(opt OpQueryOption, _ int) string {
-		if opt.QueryOp() == "" {
-			panic("Op must be provided in IsQueryOption")
-		}
-		return fmt.Sprintf("%s %s ?", getColumnName(h.joined, opt), opt.QueryOp())
-	}), " AND ")
-	h.db = h.db.Map(func(db *gorm.DB) (*gorm.DB, error) {
-		values, err := MapErr(opts, func(opt OpQueryOption, _ int) (any, error) {
-			return h.serialize(ctx, getColumnName(h.joined, opt), opt.GetValue())
-		})
-		if err != nil {
-			return nil, err
-		}
-		return db.Where(query, values...), nil
-	})
-	return h
 }
 
-func (h *applyHelper) applyRangeQueryOptions(ctx context.Context, opts []RangeQueryOption) *applyHelper {
-	if len(opts) == 0 {
-		return h
+// WithUpdateIf makes upserts update the conflicting row only if the incoming value of col compares to the
+// existing one by op, i.e. `excluded.col op table.col`, multiple conditions are combined with AND.
+// For example, WithUpdateIf(cols.UpdatedAt, sqldb.OpGt) keeps stale writes from overwriting fresher rows.
+// It is supported by Postgres and SQLite, and ignored when there are no update columns.
+// It panics if op is not one of OpEq, OpNe, OpGt, OpLt, OpGte and OpLte.
+func WithUpdateIf(col ColumnNameGetter, op QueryOp) UpsertOption {
+	if !lo.Contains([]QueryOp{OpEq, OpNe, OpGt, OpLt, OpGte, OpLte}, op) {
+		panic(fmt.Sprintf("the op %s of the upsert condition on %s is not a comparison", op, col.GetColumnName()))
 	}
-	query := strings.Join(lo.Map(opts, func(opt RangeQueryOption, _ int) string {
-		return fmt.Sprintf("%s %s (?)", getColumnName(h.joined, opt), lo.Ternary(opt.Exclude(), "NOT IN", "IN"))
-	}), " AND ")
-	h.db = h.db.Map(func(db *gorm.DB) (*gorm.DB, error) {
-		values, err := MapErr(opts, func(opt RangeQueryOption, _ int) (any, error) {
-			return MapErr(opt.GetValues(), func(v any, _ int) (any, error) {
-				return h.serialize(ctx, getColumnName(h.joined, opt), v)
+	return func(c *upsertConfig) {
+		c.conditions = append(c.conditions, upsertCondition{column: col, op: op})
+	}
+}
+
+// onConflict returns the ON CONFLICT clause of upserts.
+func (m model[T]) onConflict(db *gorm.DB, conflictColumns, updateColumns []ColumnNameGetter, opts []UpsertOption) (clause.OnConflict, error) {
+	var cfg upsertConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	names := func(columns []ColumnNameGetter) []string {
+		return lo.Map(columns, func(c ColumnNameGetter, _ int) string { return c.GetColumnName().String() })
+	}
+	oc := clause.OnConflict{
+		Columns: lo.Map(names(conflictColumns), func(name string, _ int) clause.Column { return clause.Column{Name: name} }),
+	}
+	if cfg.indexPredicate != "" {
+		oc.TargetWhere = clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: cfg.indexPredicate}}}
+	}
+	if len(updateColumns) == 0 {
+		oc.DoNothing = true
+	} else {
+		oc.DoUpdates = clause.AssignmentColumns(names(updateColumns))
+		if len(cfg.conditions) > 0 {
+			if name := db.Dialector.Name(); name == "mysql" {
+				return oc, fmt.Errorf("conditional upserts are not supported by the dialect %s", name)
+			}
+			for _, c := range cfg.conditions {
+				name := c.column.GetColumnName().Name
+				oc.Where.Exprs = append(oc.Where.Exprs, clause.Expr{
+					SQL:  fmt.Sprintf("? %s ?", dialectQueryOp(db, c.op)),
+					Vars: []any{clause.Column{Table: "excluded", Name: name}, clause.Column{Table: m.tableName, Name: name}},
+				})
+			}
+		}
+	}
+	return oc, nil
+}
+
+func (m model[T]) Query(queries ...FilterOption) Executor[T] {
+	return executor[T]{
+		model:   m,
+		queries: append(m.config.filters[:len(m.config.filters):len(m.config.filters)], queries...),
+	}
+}
+
+func (e executor[T]) DistinctOn(cols ...ColumnNameGetter) Executor[T] {
+	e.distinctOn = cols
+	return e
+}
+
+func (e executor[T]) Preload(associations ...string) Executor[T] {
+	e.preloads = append(e.preloads[:len(e.preloads):len(e.preloads)], associations...)
+	return e
+}
+
+func (e executor[T]) Apply(scopes ...Scope) Executor[T] {
+	e.queries = e.queries[:len(e.queries):len(e.queries)]
+	for _, scope := range scopes {
+		e.queries = append(e.queries, scope()...)
+	}
+	return e
+}
+
+func (e executor[T]) DescribeFilters() []FilterDescriptor {
+	return DescribeFilters(e.queries)
+}
+
+func (e executor[T]) WithIndexHint(indexName string) Executor[T] {
+	e.indexHint = indexName
+	return e
+}
+
+// applyIndexHint makes the planner of the dialect of db use the index of the hint.
+func (e executor[T]) applyIndexHint(db *gorm.DB) *gorm.DB {
+	switch db.Dialector.Name() {
+	case "mysql":
+		return db.Table(fmt.Sprintf("%s FORCE INDEX (%s)", e.tableExpr(), db.Statement.Quote(e.indexHint)))
+	case "sqlite":
+		return db.Table(fmt.Sprintf("%s INDEXED BY %s", e.tableExpr(), db.Statement.Quote(e.indexHint)))
+	case "postgres":
+		return db.Clauses(indexScanHint(fmt.Sprintf("/*+ IndexScan(%s %s) */", e.tableRef(), e.indexHint)))
+	}
+	return db
+}
+
+// indexScanHint prepends the comment of pg_hint_plan to the SELECT clause.
+type indexScanHint string
+
+func (h indexScanHint) ModifyStatement(stmt *gorm.Statement) {
+	c := stmt.Clauses["SELECT"]
+	c.BeforeExpression = clause.Expr{SQL: string(h)}
+	stmt.Clauses["SELECT"] = c
+}
+
+func (indexScanHint) Build(clause.Builder) {}
+
+func (e executor[T]) AllowGlobalUpdate() Executor[T] {
+	e.allowGlobal = true
+	return e
+}
+
+// destructiveDB returns a db instance with filters applied for Update and Delete.
+func (e executor[T]) destructiveDB(ctx context.Context, filters []FilterOption) (*gorm.DB, error) {
+	if len(e.queries) == 0 && e.config.safeDestructive && !e.allowGlobal {
+		return nil, ErrMissingFilters
+	}
+	db := e.DB(ctx)
+	if e.allowGlobal {
+		db = db.Session(&gorm.Session{AllowGlobalUpdate: true})
+	}
+	return e.newApplyHelper(db, e.qualified()).applyFilterOptions(ctx, filters).Result().Get()
+}
+
+// filters returns the filter options of the executor, which skip soft deleted rows if the model has a soft delete flag.
+func (e executor[T]) filters() []FilterOption {
+	if e.softDeleteFlag == nil {
+		return e.queries
+	}
+	return append(e.queries[:len(e.queries):len(e.queries)], NewOpQueryOption(e.softDeleteFlag.GetColumnName(), OpEq, false))
+}
+
+// scopes returns the filter options of WithScopeIf in ctx which only filter the columns of the tables of e.
+func (e executor[T]) scopes(ctx context.Context) []FilterOption {
+	tables := lo.Ternary(e.joined, e.config.joinedTables, []string{e.tableRef()})
+	return lo.Filter(ScopesFrom(ctx), func(opt FilterOption, _ int) bool {
+		return lo.Every(tables, filterTables([]FilterOption{opt}))
+	})
+}
+
+func (e executor[T]) Update(ctx context.Context, opts ...UpdateOption) (_ uint64, err error) {
+	defer func() { err = e.mapError(err) }()
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+	if len(opts) == 0 {
+		return 0, errors.New("empty options")
+	}
+	if err := e.checkWritable(lo.Map(opts, func(opt UpdateOption, _ int) ColumnNameGetter { return opt })); err != nil {
+		return 0, err
+	}
+	updateMap := map[string]any{}
+	for _, opt := range opts {
+		v, err := e.updateValue(ctx, opt, e.qualified())
+		if err != nil {
+			return 0, err
+		}
+		updateMap[getColumnName(e.joined, opt)] = v
+	}
+	if user := AuditUserFrom(ctx); user != nil && e.config.updatedBy != "" && !e.joined {
+		if _, exist := updateMap[e.config.updatedBy]; !exist {
+			updateMap[e.config.updatedBy] = user
+		}
+	}
+	db, err := e.destructiveDB(ctx, e.filters())
+	if err != nil {
+		return 0, err
+	}
+	updated := db.Model(new(T)).Updates(updateMap)
+	return uint64(updated.RowsAffected), updated.Error
+}
+
+func (e executor[T]) Delete(ctx context.Context) (err error) {
+	defer func() { err = e.mapError(err) }()
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+	db, err := e.destructiveDB(ctx, e.filters())
+	if err != nil {
+		return err
+	}
+	if e.softDeleteFlag != nil {
+		return db.Model(new(T)).Update(getColumnName(e.joined, e.softDeleteFlag), true).Error
+	}
+	return db.Delete(new(T)).Error
+}
+
+func (e executor[T]) DeleteInBatches(ctx context.Context, batchSize int) (_ uint64, err error) {
+	defer func() { err = e.mapError(err) }()
+	if e.joined {
+		return 0, errors.New("DeleteInBatches is not supported by joined models")
+	}
+	if batchSize <= 0 {
+		return 0, errors.New("batch size must be positive")
+	}
+	stmt := &gorm.Statement{DB: e.db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return 0, err
+	}
+	limited := e.db.Dialector.Name() == "mysql"
+	if !limited && len(stmt.Schema.PrimaryFieldDBNames) != 1 {
+		return 0, fmt.Errorf("DeleteInBatches requires a single primary key of %s on the dialect %s", e.tableName, e.db.Dialector.Name())
+	}
+	var total uint64
+	for {
+		deleted, err := e.deleteBatch(ctx, batchSize, limited, stmt.Schema.PrimaryFieldDBNames)
+		total += deleted
+		if err != nil || deleted < uint64(batchSize) {
+			return total, err
+		}
+	}
+}
+
+// deleteBatch deletes at most batchSize matched entities, limited by LIMIT or by a subquery selecting the primary key.
+func (e executor[T]) deleteBatch(ctx context.Context, batchSize int, limited bool, primaryKeys []string) (uint64, error) {
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+	var (
+		db  *gorm.DB
+		err error
+	)
+	if limited {
+		if db, err = e.destructiveDB(ctx, e.filters()); err != nil {
+			return 0, err
+		}
+		db = db.Limit(batchSize)
+	} else {
+		if db, err = e.destructiveDB(ctx, nil); err != nil {
+			return 0, err
+		}
+		sub := e.DB(ctx).Model(new(T))
+		if e.config.tableAlias != "" {
+			sub = sub.Table(e.tableExpr())
+		}
+		if sub, err = e.newApplyHelper(sub, e.qualified()).applyFilterOptions(ctx, e.filters()).Result().Get(); err != nil {
+			return 0, err
+		}
+		db = db.Where(fmt.Sprintf("%s IN (?)", primaryKeys[0]), sub.Select(primaryKeys[0]).Limit(batchSize))
+	}
+	if e.softDeleteFlag != nil {
+		db = db.Model(new(T)).Update(getColumnName(e.joined, e.softDeleteFlag), true)
+	} else {
+		db = db.Delete(new(T))
+	}
+	return uint64(db.RowsAffected), db.Error
+}
+
+func (e executor[T]) Restore(ctx context.Context) (_ uint64, err error) {
+	defer func() { err = e.mapError(err) }()
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+	if e.softDeleteFlag == nil {
+		return 0, errors.New("Restore is only supported by models with a soft delete flag")
+	}
+	db, err := e.destructiveDB(ctx, append(e.queries[:len(e.queries):len(e.queries)], NewOpQueryOption(e.softDeleteFlag.GetColumnName(), OpEq, true)))
+	if err != nil {
+		return 0, err
+	}
+	restored := db.Model(new(T)).Update(getColumnName(e.joined, e.softDeleteFlag), false)
+	return uint64(restored.RowsAffected), restored.Error
+}
+
+func (e executor[T]) Get(ctx context.Context) (_ T, err error) {
+	defer func() { err = e.mapError(err) }()
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+	db, err := e.queryDB(ctx)
+	if err != nil {
+		return lo.Empty[T](), err
+	}
+	var entity T
+	if e.joined {
+		var values map[string]any
+		if err := db.Take(&values).Error; err != nil {
+			return lo.Empty[T](), err
+		}
+		if entity, err = e.scan(ctx, values); err != nil {
+			return lo.Empty[T](), err
+		}
+	} else if err := db.First(&entity).Error; err != nil {
+		return entity, err
+	}
+	e.normalize(&entity)
+	return entity, nil
+}
+
+func (e executor[T]) MaxBy(ctx context.Context, col ColumnNameGetter) (T, error) {
+	return e.firstBy(ctx, col, SortOrderDescending)
+}
+
+func (e executor[T]) MinBy(ctx context.Context, col ColumnNameGetter) (T, error) {
+	return e.firstBy(ctx, col, SortOrderAscending)
+}
+
+// firstBy returns the first entity sorted by col in order.
+func (e executor[T]) firstBy(ctx context.Context, col ColumnNameGetter, order SortOrder) (T, error) {
+	entities, err := e.Find(ctx, ListOptions{Limit: 1, SortOptions: []SortOption{NewSortOption(col.GetColumnName(), order)}})
+	if err != nil {
+		return lo.Empty[T](), err
+	}
+	if len(entities) == 0 {
+		// errors of Find are already mapped.
+		return lo.Empty[T](), e.mapError(gorm.ErrRecordNotFound)
+	}
+	return entities[0], nil
+}
+
+func (e executor[T]) Pager(pageSize uint64, col ColumnNameGetter) *Pager[T] {
+	return NewPager[T](e, pageSize, col, func(entity T) (any, error) {
+		value, err := e.ValueOf(&entity, col)
+		if err != nil {
+			return nil, err
+		}
+		return reflect.Indirect(reflect.ValueOf(value)).Interface(), nil
+	})
+}
+
+func (e executor[T]) DequeueOne(ctx context.Context, extraFilters ...FilterOption) (_ T, err error) {
+	defer func() { err = e.mapError(err) }()
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+	if TransactionFrom(ctx) == nil {
+		return lo.Empty[T](), errors.New("DequeueOne must be called in a transaction")
+	}
+	if e.joined {
+		return lo.Empty[T](), errors.New("DequeueOne is not supported by joined models")
+	}
+	e.queries = append(e.queries[:len(e.queries):len(e.queries)], extraFilters...)
+	db, err := e.queryDB(ctx)
+	if err != nil {
+		return lo.Empty[T](), err
+	}
+	switch db.Dialector.Name() {
+	case "postgres", "mysql":
+		db = db.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+	}
+	var entities []T
+	if err := e.find(ctx, db, ListOptions{Limit: 1}, &entities); err != nil {
+		return lo.Empty[T](), err
+	}
+	if len(entities) == 0 {
+		return lo.Empty[T](), gorm.ErrRecordNotFound
+	}
+	return entities[0], nil
+}
+
+const (
+	groupKeyColumn   = "sqldb_group_key"
+	groupCountColumn = "sqldb_group_count"
+)
+
+func (e executor[T]) CountBy(ctx context.Context, col ColumnNameGetter, extraFilters ...FilterOption) (_ map[any]uint64, err error) {
+	defer func() { err = e.mapError(err) }()
+	return e.countBy(ctx, col, "COUNT(*)", extraFilters)
+}
+
+func (e executor[T]) DistinctCountBy(ctx context.Context, groupCol, distinctCol ColumnNameGetter, extraFilters ...FilterOption) (_ map[any]uint64, err error) {
+	defer func() { err = e.mapError(err) }()
+	return e.countBy(ctx, groupCol, fmt.Sprintf("COUNT(DISTINCT %s)", getColumnName(e.qualified(), distinctCol)), extraFilters)
+}
+
+// countBy selects the aggregate count of the rows grouped by the values of col.
+func (e executor[T]) countBy(ctx context.Context, col ColumnNameGetter, count string, extraFilters []FilterOption) (map[any]uint64, error) {
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+	e.queries, e.preloads = append(e.queries[:len(e.queries):len(e.queries)], extraFilters...), nil
+	db, err := e.queryDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+	column := getColumnName(e.qualified(), col)
+	var rows []map[string]any
+	if err := db.Select(fmt.Sprintf("%s AS %s, %s AS %s", column, groupKeyColumn, count, groupCountColumn)).
+		Group(column).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[any]uint64, len(rows))
+	for _, row := range rows {
+		key := row[groupKeyColumn]
+		if b, ok := key.([]byte); ok {
+			key = string(b)
+		}
+		var count uint64
+		if err := internalsql.ConvertAssign(&count, row[groupCountColumn]); err != nil {
+			return nil, fmt.Errorf("failed to scan the count of %v: %w", key, err)
+		}
+		counts[key] = count
+	}
+	return counts, nil
+}
+
+func (e executor[T]) Histogram(ctx context.Context, col ColumnNameGetter, buckets []Bucket) (_ []BucketCount, err error) {
+	defer func() { err = e.mapError(err) }()
+	counts := lo.Map(buckets, func(b Bucket, _ int) BucketCount { return BucketCount{Bucket: b} })
+	if len(buckets) == 0 {
+		return counts, nil
+	}
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+	e.preloads = nil
+	db, err := e.queryDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		column = getColumnName(e.qualified(), col)
+		cases  []string
+		values []any
+	)
+	for i, b := range buckets {
+		conds := []string{}
+		if b.From != nil {
+			conds = append(conds, fmt.Sprintf("%s >= ?", column))
+			values = append(values, b.From)
+		}
+		if b.To != nil {
+			conds = append(conds, fmt.Sprintf("%s < ?", column))
+			values = append(values, b.To)
+		}
+		if len(conds) == 0 {
+			conds = append(conds, fmt.Sprintf("%s IS NOT NULL", column))
+		}
+		cases = append(cases, fmt.Sprintf("WHEN %s THEN %d", strings.Join(conds, " AND "), i))
+	}
+	var rows []map[string]any
+	if err := db.Select(fmt.Sprintf("CASE %s END AS %s, COUNT(*) AS %s", strings.Join(cases, " "), groupKeyColumn, groupCountColumn), values...).
+		Group(groupKeyColumn).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if row[groupKeyColumn] == nil {
+			continue
+		}
+		var index int
+		if err := internalsql.ConvertAssign(&index, row[groupKeyColumn]); err != nil {
+			return nil, fmt.Errorf("failed to scan the bucket index: %w", err)
+		}
+		if err := internalsql.ConvertAssign(&counts[index].Count, row[groupCountColumn]); err != nil {
+			return nil, fmt.Errorf("failed to scan the count of the bucket %d: %w", index, err)
+		}
+	}
+	return counts, nil
+}
+
+func (e executor[T]) List(ctx context.Context, opts ListOptions) (entities []T, total uint64, err error) {
+	total, err = e.ListInto(ctx, opts, &entities)
+	return
+}
+
+func (e executor[T]) ListInto(ctx context.Context, opts ListOptions, dest *[]T) (_ uint64, err error) {
+	defer func() { err = e.mapError(err) }()
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+	db, err := e.listDB(ctx, opts)
+	if err != nil {
+		return 0, err
+	}
+	if opts.WindowTotal {
+		return e.findWithWindowTotal(ctx, db, opts, dest)
+	}
+	total, err := e.count(db)
+	if err != nil {
+		return 0, err
+	}
+	return total, e.find(ctx, db, opts, dest)
+}
+
+// count counts the entities of db for the total of listing, which is the number of distinct rows with DistinctOn.
+// Counting in a new session keeps the statement of db untouched, both are scoped by the same conditions including
+// the soft delete one, so the total matches the listed entities.
+func (e executor[T]) count(db *gorm.DB) (uint64, error) {
+	var t int64
+	counted := db.Session(&gorm.Session{})
+	if len(e.distinctOn) != 0 {
+		distinct, err := e.applyDistinctOn(counted, nil)
+		if err != nil {
+			return 0, err
+		}
+		counted = db.Session(&gorm.Session{NewDB: true}).Table("(?) AS sqldb_distinct", distinct)
+	}
+	if err := counted.Count(&t).Error; err != nil {
+		return 0, err
+	}
+	return uint64(t), nil
+}
+
+func (e executor[T]) ListRaw(ctx context.Context, opts ListOptions) (_ []map[string]any, _ uint64, err error) {
+	defer func() { err = e.mapError(err) }()
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+	e.preloads = nil
+	db, err := e.listDB(ctx, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := e.count(db)
+	if err != nil {
+		return nil, 0, err
+	}
+	if db, err = e.applyListOptions(db, opts); err != nil {
+		return nil, 0, err
+	}
+	// rows are scanned without gorm, which would scan the values into the types of the fields of T.
+	rows, err := db.Rows()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, 0, err
+	}
+	results := []map[string]any{}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		if err := rows.Scan(lo.Map(values, func(_ any, i int) any { return &values[i] })...); err != nil {
+			return nil, 0, err
+		}
+		result := make(map[string]any, len(columns))
+		for i, column := range columns {
+			if b, ok := values[i].([]byte); ok {
+				values[i] = string(b)
+			}
+			result[column] = values[i]
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return results, total, nil
+}
+
+func (e executor[T]) ListWithAggregate(ctx context.Context, opts ListOptions, aggs ...Aggregate) (_ []T, _ map[string]any, _ uint64, err error) {
+	defer func() { err = e.mapError(err) }()
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+	db, err := e.listDB(ctx, opts)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	selects := []string{fmt.Sprintf("COUNT(*) AS %s", windowTotalColumn)}
+	for _, agg := range aggs {
+		if agg.As == nil {
+			return nil, nil, 0, fmt.Errorf("alias of aggregate %s is missing", agg.Func)
+		}
+		selects = append(selects, fmt.Sprintf("%s AS %s", aggregateSQL(e.qualified(), agg.Func, agg.Column), db.Statement.Quote(agg.As.GetColumnName().Name)))
+	}
+	// the aggregates are computed over all matched entities in a new session, the list options only page the entities.
+	values := make([]any, len(selects))
+	if err := db.Session(&gorm.Session{}).Select(strings.Join(selects, ", ")).Row().
+		Scan(lo.Map(values, func(_ any, i int) any { return &values[i] })...); err != nil {
+		return nil, nil, 0, err
+	}
+	var total uint64
+	if err := internalsql.ConvertAssign(&total, values[0]); err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to scan the total: %w", err)
+	}
+	results := make(map[string]any, len(aggs))
+	for i, agg := range aggs {
+		v := values[i+1]
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+		}
+		results[agg.As.GetColumnName().Name] = v
+	}
+	var entities []T
+	if err := e.find(ctx, db, opts, &entities); err != nil {
+		return nil, nil, 0, err
+	}
+	return entities, results, total, nil
+}
+
+const windowTotalColumn = "sqldb_window_total"
+
+// windowTotalRow is a row of the entity with the total computed by the window function.
+type windowTotalRow[T any] struct {
+	Entity T      `gorm:"embedded"`
+	Total  uint64 `gorm:"column:sqldb_window_total"`
+}
+
+// findWithWindowTotal is like find but also returns the total which is selected by COUNT(*) OVER ().
+func (e executor[T]) findWithWindowTotal(ctx context.Context, db *gorm.DB, opts ListOptions, dest *[]T) (uint64, error) {
+	if len(e.preloads) != 0 {
+		return 0, errors.New("preloading associations is not supported with the window total")
+	}
+	if len(e.distinctOn) != 0 {
+		// the window is computed before DISTINCT ON, so it would count the rows rather than the distinct ones.
+		return 0, errors.New("DISTINCT ON is not supported with the window total")
+	}
+	e = e.withListColumns(opts)
+	db, err := e.applyListOptions(db, opts)
+	if err != nil {
+		return 0, err
+	}
+	selects, vars := selectList(db)
+	db = db.Select(fmt.Sprintf("%s, COUNT(*) OVER () AS %s", selects, windowTotalColumn), vars...)
+
+	var total uint64
+	entities := (*dest)[:0]
+	if e.joined {
+		if err := e.scanJoinedRows(ctx, db, func(values map[string]any, entity T) error {
+			if err := internalsql.ConvertAssign(&total, values[windowTotalColumn]); err != nil {
+				return fmt.Errorf("failed to scan the window total: %w", err)
+			}
+			entities = append(entities, entity)
+			return nil
+		}); err != nil {
+			return 0, err
+		}
+	} else {
+		// rows are scanned one by one into dest, so that no other slice of the entities is allocated.
+		rows, err := db.Rows()
+		if err != nil {
+			return 0, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var row windowTotalRow[T]
+			if err := db.ScanRows(rows, &row); err != nil {
+				return 0, err
+			}
+			total = row.Total
+			entities = append(entities, row.Entity)
+		}
+		if err := rows.Err(); err != nil {
+			return 0, err
+		}
+		if entities == nil {
+			// like db.Find, an empty result is an empty slice.
+			entities = []T{}
+		}
+	}
+	*dest = entities
+	for i := range *dest {
+		e.normalize(&(*dest)[i])
+	}
+	if len(entities) == 0 && opts.Offset != 0 {
+		// no row carries the total when the offset is out of range.
+		var t int64
+		db, err := e.listDB(ctx, opts)
+		if err != nil {
+			return 0, err
+		}
+		if err := db.Count(&t).Error; err != nil {
+			return 0, err
+		}
+		total = uint64(t)
+	}
+	return total, nil
+}
+
+func (e executor[T]) Find(ctx context.Context, opts ListOptions) (_ []T, err error) {
+	defer func() { err = e.mapError(err) }()
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+	var entities []T
+	db, err := e.listDB(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return entities, e.find(ctx, db, opts, &entities)
+}
+
+// queryDB returns a db instance with all filter options and preloads of the executor applied.
+func (e executor[T]) queryDB(ctx context.Context) (*gorm.DB, error) {
+	if e.joined && len(e.preloads) != 0 {
+		return nil, errors.New("preloading associations is not supported by joined models")
+	}
+	if e.joined && e.indexHint != "" {
+		return nil, errors.New("index hints are not supported by joined models")
+	}
+	filters := e.filters()
+	filters = append(filters[:len(filters):len(filters)], e.scopes(ctx)...)
+	db, err := e.newApplyHelper(lo.TernaryF(e.joined,
+		func() *gorm.DB { return e.readDB(ctx) },
+		func() *gorm.DB {
+			db := e.readDB(ctx).Model(new(T))
+			if e.config.tableAlias != "" {
+				db = db.Table(e.tableExpr())
+			}
+			if e.indexHint != "" {
+				db = e.applyIndexHint(db)
+			}
+			return db
+		},
+	), e.qualified()).applyFilterOptions(ctx, filters).Result().Get()
+	if err != nil {
+		return nil, err
+	}
+	for _, association := range e.preloads {
+		db = db.Preload(association)
+	}
+	return db, nil
+}
+
+// find applies the list options to db and scans the results into dest.
+func (e executor[T]) find(ctx context.Context, db *gorm.DB, opts ListOptions, dest *[]T) error {
+	e = e.withListColumns(opts)
+	db, err := e.applyListOptions(db, opts)
+	if err != nil {
+		return err
+	}
+	if e.joined {
+		entities := (*dest)[:0]
+		if err := e.scanJoinedRows(ctx, db, func(_ map[string]any, entity T) error {
+			entities = append(entities, entity)
+			return nil
+		}); err != nil {
+			return err
+		}
+		*dest = entities
+	} else if err := db.Find(dest).Error; err != nil {
+		return err
+	}
+	for i := range *dest {
+		e.normalize(&(*dest)[i])
+	}
+	return nil
+}
+
+// scanJoinedRows runs the query of db and calls fn with the values and the joined entity of each row, rows are
+// scanned one by one so that the values of only one row are held in a map at a time.
+func (e executor[T]) scanJoinedRows(ctx context.Context, db *gorm.DB, fn func(values map[string]any, entity T) error) error {
+	rows, err := db.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var values map[string]any
+		if err := db.ScanRows(rows, &values); err != nil {
+			return err
+		}
+		for column, v := range values {
+			// columns without a declared type such as computed ones are scanned into *any by ScanRows.
+			if p, ok := v.(*any); ok {
+				values[column] = *p
+			}
+		}
+		entity, err := e.scan(ctx, values)
+		if err != nil {
+			return err
+		}
+		if err := fn(values, entity); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (e executor[T]) Rows(ctx context.Context, opts ListOptions) (*sql.Rows, error) {
+	_, rows, err := e.rows(ctx, opts)
+	return rows, e.mapError(err)
+}
+
+func (e executor[T]) rows(ctx context.Context, opts ListOptions) (*gorm.DB, *sql.Rows, error) {
+	db, err := e.listDB(ctx, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if db, err = e.applyListOptions(db, opts); err != nil {
+		return nil, nil, err
+	}
+	rows, err := db.Rows()
+	return db, rows, err
+}
+
+func (e executor[T]) Stream(ctx context.Context, opts ListOptions) (<-chan T, <-chan error) {
+	entities, errs := make(chan T), make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(entities)
+		if err := e.iterate(ctx, opts, func(entity T) error {
+			select {
+			case entities <- entity:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}); err != nil {
+			errs <- e.mapError(err)
+		}
+	}()
+	return entities, errs
+}
+
+// iterate lists entities one by one with the list options and calls fn with each of them.
+func (e executor[T]) iterate(ctx context.Context, opts ListOptions, fn func(T) error) error {
+	e = e.withListColumns(opts)
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+	db, rows, err := e.rows(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var entity T
+		if e.joined {
+			var values map[string]any
+			if err := db.ScanRows(rows, &values); err != nil {
+				return err
+			}
+			if entity, err = e.scan(ctx, values); err != nil {
+				return err
+			}
+		} else if err := db.ScanRows(rows, &entity); err != nil {
+			return err
+		}
+		e.normalize(&entity)
+		if err := fn(entity); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// withListColumns returns e scanning joined entities from the aliases of opts.Columns, which override the selected
+// columns of joined models.
+func (e executor[T]) withListColumns(opts ListOptions) executor[T] {
+	if e.joined && len(opts.Columns) != 0 {
+		e.config.selectAliases = selectAliases(opts.Columns)
+	}
+	return e
+}
+
+// applyListOptions applies the pagination and sort options to db.
+func (e executor[T]) applyListOptions(db *gorm.DB, opts ListOptions) (*gorm.DB, error) {
+	if len(opts.Columns) != 0 {
+		if e.joined {
+			selects, vars := joinedSelects(db, opts.Columns)
+			db = db.Select(selects, vars...)
+		} else {
+			var vars []any
+			selects := lo.Map(opts.Columns, func(col ColumnNameGetter, _ int) string {
+				name := getColumnName(e.qualified(), col)
+				alias := selectAlias(col, col.GetColumnName().Name)
+				expr, exprVars := selectExpr(col, name)
+				vars = append(vars, exprVars...)
+				if expr != name || alias != col.GetColumnName().Name {
+					return fmt.Sprintf("%s AS %s", expr, db.Statement.Quote(alias))
+				}
+				return name
 			})
+			if len(vars) != 0 {
+				db = db.Select(strings.Join(selects, ","), vars...)
+			} else {
+				db = db.Select(selects)
+			}
+		}
+	}
+	if opts.Limit != 0 {
+		db = db.Limit(int(opts.Limit))
+	}
+	if opts.Offset != 0 {
+		db = db.Offset(int(opts.Offset))
+	}
+
+	sortOpts := opts.SortOptions
+	if len(sortOpts) == 0 {
+		sortOpts = e.config.defaultSort
+	}
+	if lo.ContainsBy(sortOpts, func(opt SortOption) bool { _, ok := opt.(ValuesSortOption); return ok }) {
+		orderBy, err := e.orderByExpr(db, sortOpts)
+		if err != nil {
+			return nil, err
+		}
+		if orderBy.SQL != "" {
+			db = db.Clauses(clause.OrderBy{Expression: orderBy})
+		}
+	} else {
+		for _, opt := range sortOpts {
+			db = db.Order(fmt.Sprintf("%s %s", getColumnName(e.qualified(), opt), opt.GetSortOrder()))
+		}
+	}
+	if len(e.distinctOn) != 0 {
+		return e.applyDistinctOn(db, sortOpts)
+	}
+	return db, nil
+}
+
+// listDB returns the db of queryDB which reads the data as of opts.AsOf if the model is created with
+// WithAsOfSystemTime. It is the only place applying AsOf, so that the entities and totals of all listing methods of
+// both plain and joined models are read from the same snapshot.
+func (e executor[T]) listDB(ctx context.Context, opts ListOptions) (*gorm.DB, error) {
+	db, err := e.queryDB(ctx)
+	if err != nil || opts.AsOf.IsZero() || !e.config.asOfSystemTime {
+		return db, err
+	}
+	return db.Clauses(asOfSystemTime(opts.AsOf)), nil
+}
+
+// asOfSystemTime appends `AS OF SYSTEM TIME` to the FROM clause, after the joined tables.
+type asOfSystemTime time.Time
+
+func (t asOfSystemTime) ModifyStatement(stmt *gorm.Statement) {
+	c := stmt.Clauses["FROM"]
+	// the timestamp is a constant formatted by us, CockroachDB does not accept placeholders here.
+	c.AfterExpression = clause.Expr{SQL: fmt.Sprintf("AS OF SYSTEM TIME '%s'", time.Time(t).UTC().Format("2006-01-02 15:04:05.999999-07:00"))}
+	stmt.Clauses["FROM"] = c
+}
+
+func (asOfSystemTime) Build(clause.Builder) {}
+
+// orderByExpr builds the ORDER BY expression of sort options which contain ValuesSortOptions,
+// values are bound as parameters so the expression is built as a whole.
+func (e executor[T]) orderByExpr(db *gorm.DB, sortOpts []SortOption) (clause.Expr, error) {
+	var (
+		terms = make([]string, 0, len(sortOpts))
+		vars  []any
+	)
+	for _, opt := range sortOpts {
+		column := getColumnName(e.qualified(), opt)
+		valuesOpt, ok := opt.(ValuesSortOption)
+		if !ok {
+			terms = append(terms, fmt.Sprintf("%s %s", column, opt.GetSortOrder()))
+			continue
+		}
+		values, err := MapErr(valuesOpt.GetValues(), func(v any, _ int) (any, error) {
+			return e.serialize(db.Statement.Context, opt.GetColumnName().String(), v)
+		})
+		if err != nil {
+			return clause.Expr{}, err
+		}
+		if len(values) == 0 {
+			continue
+		}
+		// CASE is portable and compares the values with the column of any type, values not in the list are sorted last.
+		terms = append(terms, fmt.Sprintf("CASE %s %s ELSE %d END", column,
+			strings.Join(lo.Times(len(values), func(i int) string { return fmt.Sprintf("WHEN ? THEN %d", i) }), " "), len(values)))
+		vars = append(vars, values...)
+	}
+	return clause.Expr{SQL: strings.Join(terms, ","), Vars: vars}, nil
+}
+
+func (e executor[T]) applyDistinctOn(db *gorm.DB, sortOpts []SortOption) (*gorm.DB, error) {
+	if name := db.Dialector.Name(); name != "postgres" {
+		return nil, fmt.Errorf("DISTINCT ON is not supported by the dialect %s", name)
+	}
+	columns := lo.Map(e.distinctOn, func(getter ColumnNameGetter, _ int) string { return getColumnName(e.qualified(), getter) })
+	if len(sortOpts) != 0 {
+		leading := lo.Map(sortOpts[:lo.Min([]int{len(columns), len(sortOpts)})],
+			func(opt SortOption, _ int) string { return getColumnName(e.qualified(), opt) })
+		if len(leading) != len(columns) || len(lo.Intersect(leading, columns)) != len(columns) {
+			return nil, fmt.Errorf("the leading sort options %v must match the DISTINCT ON columns %v", leading, columns)
+		}
+	}
+	selects, vars := selectList(db)
+	return db.Select(fmt.Sprintf("DISTINCT ON (%s) %s", strings.Join(columns, ","), selects), vars...), nil
+}
+
+// normalize adjusts the scanned entity according to the model config.
+func (e executor[T]) normalize(entity *T) {
+	if !e.config.timeInUTC {
+		return
+	}
+	_ = e.iterateColumns(entity, func(_ ColumnNameGetter, fieldAddr reflect.Value, _ reflect.StructField) error {
+		if !fieldAddr.IsValid() {
+			return nil
+		}
+		v := fieldAddr.Elem().FieldByName("V")
+		switch t := v.Interface().(type) {
+		case time.Time:
+			v.Set(reflect.ValueOf(t.UTC()))
+		case *time.Time:
+			if t != nil {
+				utc := t.UTC()
+				v.Set(reflect.ValueOf(&utc))
+			}
+		}
+		return nil
+	})
+}
+
+// updateValue returns the value used to update the column of opt,
+// a ColumnRef value is rendered as a column and qualified with its table name if qualified is true.
+func (e executor[T]) updateValue(ctx context.Context, opt UpdateOption, qualified bool) (any, error) {
+	switch v := opt.GetValue().(type) {
+	case ColumnRef:
+		return clause.Expr{SQL: getColumnName(qualified, v)}, nil
+	case clause.Expr:
+		return v, nil
+	case Subquery:
+		return v.expr(ctx)
+	case FlagUpdate:
+		return clause.Expr{
+			SQL:  fmt.Sprintf(lo.Ternary(v.Clear, "%s & ~?", "%s | ?"), getColumnName(qualified, opt)),
+			Vars: []any{v.Mask},
+		}, nil
+	default:
+		return e.serialize(ctx, opt.GetColumnName().String(), v)
+	}
+}
+
+func (e executor[T]) serialize(ctx context.Context, column string, v any) (any, error) {
+	return serializeValue(ctx, e.columnSerializers, column, v)
+}
+
+// serializeValue serializes v with the serializer of the column if there is one.
+func serializeValue(ctx context.Context, serializers map[string]serializer, column string, v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	value := v
+	if s, exist := serializers[column]; exist {
+		v, err := s.value(ctx, v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize the value of the column %s: %w", column, err)
+		}
+		value = v
+	}
+	return value, nil
+}
+
+func (e executor[T]) scan(ctx context.Context, values map[string]any) (T, error) {
+	target := *e.columns
+	if err := iterateFields(&target, true, func(fieldAddr reflect.Value, path []reflect.StructField) (bool, error) {
+		fieldPath := strings.Join(lo.Map(path, func(sf reflect.StructField, _ int) string { return sf.Name }), ".")
+		if cg, exist := e.fieldPathToColumn[fieldPath]; exist {
+			columnName := cg.GetColumnName().String()
+			v, exist := values[columnName]
+			if alias, aliased := e.config.selectAliases[columnName]; aliased && !exist {
+				v = values[alias]
+			}
+			if v == nil {
+				return false, nil
+			}
+			var err error
+			if s, exist := e.columnSerializers[columnName]; exist {
+				err = s.scan(ctx, fieldAddr.Interface(), v)
+			} else {
+				err = fieldAddr.Interface().(interface{ Scan(any) error }).Scan(v)
+			}
+			if err != nil {
+				return false, fmt.Errorf("failed to scan value %v of the column %s into field %s: %w", v, columnName, fieldPath, err)
+			}
+			return false, nil
+		}
+		if isEmbeddedPtr(path[len(path)-1]) && !fieldAddr.Elem().IsNil() {
+			// the embedded struct must not be shared with the columns of the model.
+			clone := reflect.New(fieldAddr.Type().Elem().Elem())
+			clone.Elem().Set(fieldAddr.Elem().Elem())
+			fieldAddr.Elem().Set(clone)
+		}
+		return true, nil
+	}); err != nil {
+		return lo.Empty[T](), err
+	}
+	return target, nil
+}
+
+// BuildWhere applies the filter options to db as WHERE conditions, it allows using filter options in hand-written queries.
+// Column names are not qualified with table names and values are not serialized by the serializers of columns.
+func BuildWhere(db *gorm.DB, opts []FilterOption) (*gorm.DB, error) {
+	return newApplyHelper(db, false, nil).applyFilterOptions(db.Statement.Context, opts).Result().Get()
+}
+
+// iterateColumns calls fn with the address of each column field of entity in declaration order. entity is never
+// changed by the iteration, the address is invalid for the columns behind nil embedded pointers, which are NULL.
+// Use allocColumn to set such columns.
+func (m model[T]) iterateColumns(entity *T, fn func(column ColumnNameGetter, fieldAddr reflect.Value, sf reflect.StructField) error) error {
+	return iterateFields(entity, false, func(fieldAddr reflect.Value, path []reflect.StructField) (bool, error) {
+		fieldPath := strings.Join(lo.Map(path, func(sf reflect.StructField, _ int) string { return sf.Name }), ".")
+		if cg, exist := m.fieldPathToColumn[fieldPath]; exist {
+			return false, fn(cg, fieldAddr, path[len(path)-1])
+		}
+		return true, nil
+	})
+}
+
+// allocColumn returns the address of the field of column in entity, the nil embedded pointers on the way to it are
+// allocated while others are left untouched.
+func (m model[T]) allocColumn(entity *T, column ColumnNameGetter) (reflect.Value, error) {
+	var target string
+	for fieldPath, cg := range m.fieldPathToColumn {
+		if cg.GetColumnName().String() == column.GetColumnName().String() {
+			target = fieldPath
+		}
+	}
+	var field reflect.Value
+	if err := iterateFields(entity, true, func(fieldAddr reflect.Value, path []reflect.StructField) (bool, error) {
+		fieldPath := strings.Join(lo.Map(path, func(sf reflect.StructField, _ int) string { return sf.Name }), ".")
+		if fieldPath == target {
+			field = fieldAddr
+			return false, nil
+		}
+		return strings.HasPrefix(target, fieldPath+"."), nil
+	}); err != nil {
+		return reflect.Value{}, err
+	}
+	if !field.IsValid() {
+		return reflect.Value{}, fmt.Errorf("the column %s does not belong to the table %s", column.GetColumnName(), m.tableName)
+	}
+	return field, nil
+}
+
+// qualified reports whether column names are qualified with table names in queries.
+func (m model[T]) qualified() bool {
+	return m.joined || m.config.qualifiedColumns
+}
+
+// newApplyHelper returns an applyHelper which serializes values and matches fuzzy queries with the config of the model.
+func (m model[T]) newApplyHelper(db *gorm.DB, qualified bool) *applyHelper {
+	h := newApplyHelper(db, qualified, m.columnSerializers)
+	h.collation = m.config.collation
+	h.valuesListThreshold = m.config.valuesListThreshold
+	return h
+}
+
+// applyHelper applies filter options to a db instance, column names are qualified with table names if qualified is true.
+type applyHelper struct {
+	db          mo.Result[*gorm.DB]
+	serializers map[string]serializer
+	qualified   bool
+	collation   string
+	// valuesListThreshold is the number of values above which IN lists are compiled into VALUES lists, 0 disables it.
+	valuesListThreshold int
+}
+
+func newApplyHelper(db *gorm.DB, qualified bool, serializers map[string]serializer) *applyHelper {
+	return &applyHelper{db: mo.Ok(db), serializers: serializers, qualified: qualified}
+}
+
+// serialize serializes v with the serializer of the column cn. Serializers of joined models are keyed by the full
+// names, which columns of the joined models themselves have as well, so the full name is tried too.
+func (h *applyHelper) serialize(ctx context.Context, cn ColumnName, v any) (any, error) {
+	return serializeValue(ctx, h.serializers, h.serializerKey(cn), v)
+}
+
+// serializerKey returns the key of the serializer of the column cn.
+func (h *applyHelper) serializerKey(cn ColumnName) string {
+	if _, exist := h.serializers[cn.String()]; !exist && h.qualified {
+		if _, exist := h.serializers[cn.Full()]; exist {
+			return cn.Full()
+		}
+	}
+	return cn.String()
+}
+
+func (h *applyHelper) Result() mo.Result[*gorm.DB] {
+	return h.db
+}
+
+func (h *applyHelper) applyFilterOptions(ctx context.Context, opts []FilterOption) *applyHelper {
+	filterOpts := parseFilterOptions(opts)
+	return h.applyOpJoinOptions(filterOpts.opJoinOptions).
+		applyOpQueryOptions(ctx, filterOpts.opQueryOptions).
+		applyRangeQueryOptions(ctx, filterOpts.rangeQueryOptions).
+		applyFuzzyQueryOptions(ctx, filterOpts.fuzzyQueryOptions).
+		applyGroupOptions(ctx, filterOpts.groupOptions)
+}
+
+func (h *applyHelper) applyGroupOptions(ctx context.Context, opts []GroupOption) *applyHelper {
+	for _, opt := range opts {
+		opt := opt
+		h.db = h.db.Map(func(db *gorm.DB) (*gorm.DB, error) {
+			expr, err := h.groupExpr(ctx, db, opt)
+			if err != nil {
+				return nil, err
+			}
+			if expr.SQL == "" {
+				return db, nil
+			}
+			return db.Where(expr), nil
 		})
+	}
+	return h
+}
+
+// groupExpr builds the condition of the group, every member is wrapped in parentheses so nested groups keep their precedence.
+func (h *applyHelper) groupExpr(ctx context.Context, db *gorm.DB, group GroupOption) (clause.Expr, error) {
+	if rv, ok := group.(rowValuesOption); ok && lo.Contains([]string{"postgres", "mysql", "sqlite"}, db.Dialector.Name()) {
+		return h.rowValuesExpr(ctx, rv)
+	}
+	var (
+		members []string
+		vars    []any
+	)
+	for _, opt := range group.GetFilterOptions() {
+		if sub, ok := opt.(GroupOption); ok {
+			expr, err := h.groupExpr(ctx, db, sub)
+			if err != nil {
+				return clause.Expr{}, err
+			}
+			if expr.SQL != "" {
+				members, vars = append(members, "?"), append(vars, expr)
+			}
+			continue
+		}
+		sub := *h
+		sub.db = mo.Ok(db.Session(&gorm.Session{NewDB: true}))
+		filtered, err := sub.applyFilterOptions(ctx, []FilterOption{opt}).Result().Get()
 		if err != nil {
-			return nil, err
+			return clause.Expr{}, err
+		}
+		if where, ok := filtered.Statement.Clauses["WHERE"].Expression.(clause.Where); ok && len(where.Exprs) != 0 {
+			members, vars = append(members, "(?)"), append(vars, where)
+		}
+	}
+	if len(members) == 0 {
+		return clause.Expr{}, nil
+	}
+	switch op := group.GroupOp(); op {
+	case GroupOpAnd:
+		return clause.Expr{SQL: "(" + strings.Join(members, " AND ") + ")", Vars: vars}, nil
+	case GroupOpOr:
+		return clause.Expr{SQL: "(" + strings.Join(members, " OR ") + ")", Vars: vars}, nil
+	case GroupOpNot:
+		return clause.Expr{SQL: "NOT (" + strings.Join(members, " AND ") + ")", Vars: vars}, nil
+	default:
+		return clause.Expr{}, fmt.Errorf("invalid group operator %s", op)
+	}
+}
+
+// rowValuesExpr builds the row value comparison of rv, e.g. `(created_at, id) > (?, ?)`.
+func (h *applyHelper) rowValuesExpr(ctx context.Context, rv rowValuesOption) (clause.Expr, error) {
+	columns := lo.Map(rv.columns, func(col ColumnNameGetter, _ int) string { return getColumnName(h.qualified, col) })
+	vars, err := MapErr(rv.values, func(v any, i int) (any, error) {
+		return h.serialize(ctx, rv.columns[i].GetColumnName(), v)
+	})
+	if err != nil {
+		return clause.Expr{}, err
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(vars)), ", ")
+	return clause.Expr{SQL: fmt.Sprintf("(%s) %s (%s)", strings.Join(columns, ", "), rv.op, placeholders), Vars: vars}, nil
+}
+
+func (h *applyHelper) applyOpJoinOptions(opts []OpJoinOption) *applyHelper {
+	if len(opts) == 0 {
+		return h
+	}
+	h.db = h.db.Map(func(db *gorm.DB) (*gorm.DB, error) {
+		query := strings.Join(lo.Map(opts, func(opt OpJoinOption, _ int) string {
+			return fmt.Sprintf("%s %s %s",
+				getColumnName(h.qualified, opt.GetLeftColumnName()), dialectQueryOp(db, opt.QueryOp()), getColumnName(h.qualified, opt.GetRightColumnName()))
+		}), " AND ")
+		return db.Where(query), nil
+	})
+	return h
+}
+
+func (h *applyHelper) applyOpQueryOptions(ctx context.Context, opts []OpQueryOption) *applyHelper {
+	if len(opts) == 0 {
+		return h
+	}
+	h.db = h.db.Map(func(db *gorm.DB) (*gorm.DB, error) {
+		var (
+			queries = make([]string, 0, len(opts))
+			values  []any
+		)
+		for _, opt := range opts {
+			if opt.QueryOp() == "" {
+				panic("Op must be provided in IsQueryOption")
+			}
+			column := getColumnName(h.qualified, opt)
+			// expressions are inlined rather than bound as parameters.
+			if expr, ok := opt.GetValue().(clause.Expr); ok {
+				queries = append(queries, fmt.Sprintf("%s %s %s", column, dialectQueryOp(db, opt.QueryOp()), expr.SQL))
+				values = append(values, expr.Vars...)
+				continue
+			}
+			if opt.QueryOp() == OpHasFlag {
+				queries = append(queries, fmt.Sprintf("%s & ? = ?", column))
+				values = append(values, opt.GetValue(), opt.GetValue())
+				continue
+			}
+			if q, ok := opt.GetValue().(QuantifiedValues); ok {
+				query, vars, err := h.quantifiedQuery(ctx, db, column, opt, q)
+				if err != nil {
+					return nil, err
+				}
+				queries = append(queries, query)
+				values = append(values, vars...)
+				continue
+			}
+			if opt.QueryOp() == OpJSONContains {
+				if name := db.Dialector.Name(); name != "postgres" {
+					return nil, fmt.Errorf("JSONB containment is not supported by the dialect %s", name)
+				}
+				raw, err := json.Marshal(opt.GetValue())
+				if err != nil {
+					return nil, fmt.Errorf("failed to marshal the value of the column %s: %w", column, err)
+				}
+				queries = append(queries, fmt.Sprintf("%s @> ?::jsonb", column))
+				values = append(values, string(raw))
+				continue
+			}
+			if raw, ok := opt.GetValue().(RawValue); ok {
+				queries = append(queries, fmt.Sprintf("%s %s ?", column, dialectQueryOp(db, opt.QueryOp())))
+				values = append(values, raw.V)
+				continue
+			}
+			v, err := h.serialize(ctx, opt.GetColumnName(), opt.GetValue())
+			if err != nil {
+				return nil, err
+			}
+			placeholder := "?"
+			// serialized JSON is compared in the canonical form of the dialect so that the formatting does not matter.
+			if _, isJSON := h.serializers[h.serializerKey(opt.GetColumnName())].(jsonSerializer); isJSON &&
+				(opt.QueryOp() == OpEq || opt.QueryOp() == OpNe) {
+				column, placeholder = canonicalJSON(db, column), canonicalJSON(db, placeholder)
+			}
+			queries = append(queries, fmt.Sprintf("%s %s %s", column, dialectQueryOp(db, opt.QueryOp()), placeholder))
+			values = append(values, v)
+		}
+		return db.Where(strings.Join(queries, " AND "), values...), nil
+	})
+	return h
+}
+
+// quantifiedQuery returns the condition comparing column with the quantified values q using the operator of opt.
+func (h *applyHelper) quantifiedQuery(ctx context.Context, db *gorm.DB, column string, opt OpQueryOption, q QuantifiedValues) (string, []any, error) {
+	values, err := MapErr(q.Values, func(v any, _ int) (any, error) {
+		return h.serialize(ctx, opt.GetColumnName(), v)
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	op, anyOf := opt.QueryOp(), q.Quantifier == QuantifierAny
+	if db.Dialector.Name() == "postgres" {
+		// the array is bound as a single literal, whose type is inferred from the column by Postgres.
+		array, err := pgArray(values)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to build the array of the column %s: %w", column, err)
+		}
+		return fmt.Sprintf("%s %s %s(?)", column, op, q.Quantifier), []any{array}, nil
+	}
+	switch {
+	case len(values) == 0:
+		return lo.Ternary(anyOf, "1 = 0", "1 = 1"), nil, nil
+	case op == OpEq && anyOf:
+		return fmt.Sprintf("%s IN (?)", column), []any{values}, nil
+	case op == OpNe && !anyOf:
+		return fmt.Sprintf("%s NOT IN (?)", column), []any{values}, nil
+	}
+	comparisons := lo.Times(len(values), func(int) string { return fmt.Sprintf("%s %s ?", column, op) })
+	return "(" + strings.Join(comparisons, lo.Ternary(anyOf, " OR ", " AND ")) + ")", values, nil
+}
+
+// pgArray formats values as a Postgres array literal.
+func pgArray(values []any) (string, error) {
+	var (
+		elems = make([]string, len(values))
+		quote = func(s string) string { return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"` }
+	)
+	for i, v := range values {
+		if valuer, ok := v.(driver.Valuer); ok {
+			var err error
+			if v, err = valuer.Value(); err != nil {
+				return "", err
+			}
+		}
+		switch tv := v.(type) {
+		case nil:
+			elems[i] = "NULL"
+			continue
+		case time.Time:
+			elems[i] = quote(tv.Format(time.RFC3339Nano))
+			continue
+		}
+		switch rv := reflect.ValueOf(v); rv.Kind() {
+		case reflect.String:
+			elems[i] = quote(rv.String())
+		case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+			elems[i] = fmt.Sprint(v)
+		default:
+			return "", fmt.Errorf("values of type %T can not be elements of an array", v)
+		}
+	}
+	return "{" + strings.Join(elems, ",") + "}", nil
+}
+
+func (h *applyHelper) applyRangeQueryOptions(ctx context.Context, opts []RangeQueryOption) *applyHelper {
+	if len(opts) == 0 {
+		return h
+	}
+	h.db = h.db.Map(func(db *gorm.DB) (*gorm.DB, error) {
+		var (
+			queries = make([]string, 0, len(opts))
+			vars    []any
+		)
+		for _, opt := range opts {
+			values, err := MapErr(opt.GetValues(), func(v any, _ int) (any, error) {
+				return h.serialize(ctx, opt.GetColumnName(), v)
+			})
+			if err != nil {
+				return nil, err
+			}
+			query := fmt.Sprintf("%s %s ", getColumnName(h.qualified, opt), lo.Ternary(opt.Exclude(), "NOT IN", "IN"))
+			if h.valuesListThreshold > 0 && len(values) > h.valuesListThreshold {
+				row := lo.Ternary(db.Dialector.Name() == "mysql", "ROW(?)", "(?)")
+				queries = append(queries, query+"(VALUES "+strings.Join(lo.Times(len(values), func(int) string { return row }), ",")+")")
+				vars = append(vars, values...)
+			} else {
+				queries = append(queries, query+"(?)")
+				vars = append(vars, values)
+			}
 		}
-		return db.Where(query, values...), nil
+		return db.Where(strings.Join(queries, " AND "), vars...), nil
 	})
 	return h
 }
@@ -447,11 +2418,23 @@ func (h *applyHelper) applyFuzzyQueryOptions(ctx context.Context, opts []FuzzyQu
 		return h
 	}
 	lo.ForEach(opts, func(opt FuzzyQueryOption, _ int) {
-		queries := lo.Map(opt.GetValues(), func(_ any, _ int) string {
-			return fmt.Sprintf("%s LIKE ?", getColumnName(h.joined, opt))
-		})
-		values := lo.Map(opt.GetValues(), func(v any, _ int) any { return fmt.Sprintf("%%%v%%", v) })
 		h.db = h.db.Map(func(db *gorm.DB) (*gorm.DB, error) {
+			column, query := getColumnName(h.qualified, opt), "%s LIKE ?"
+			values := lo.Map(opt.GetValues(), func(v any, _ int) any { return fmt.Sprintf("%%%v%%", v) })
+			if opt.CaseSensitive() {
+				switch db.Dialector.Name() {
+				case "mysql":
+					query += " COLLATE " + lo.Ternary(h.collation == "", "utf8mb4_bin", h.collation)
+				case "sqlite":
+					query = "instr(%s, ?) > 0"
+					values = lo.Map(opt.GetValues(), func(v any, _ int) any { return fmt.Sprint(v) })
+				default:
+					if h.collation != "" {
+						query += " COLLATE " + h.collation
+					}
+				}
+			}
+			queries := lo.Map(opt.GetValues(), func(_ any, _ int) string { return fmt.Sprintf(query, column) })
 			return db.Where(strings.Join(queries, " OR "), values...), nil
 		})
 	})
@@ -459,9 +2442,11 @@ func (h *applyHelper) applyFuzzyQueryOptions(ctx context.Context, opts []FuzzyQu
 }
 
 type filterOptions struct {
+	opJoinOptions     []OpJoinOption
 	opQueryOptions    []OpQueryOption
 	rangeQueryOptions []RangeQueryOption
 	fuzzyQueryOptions []FuzzyQueryOption
+	groupOptions      []GroupOption
 }
 
 func parseFilterOptions(opts []FilterOption) filterOptions {
@@ -469,11 +2454,17 @@ func parseFilterOptions(opts []FilterOption) filterOptions {
 	for _, opt := range opts {
 		switch opt.GetFilterOptionType() {
 		case FilterOptionTypeOpQuery:
-			res.opQueryOptions = append(res.opQueryOptions, opt.(OpOption).MustRight())
+			if op := opt.(OpOption); op.IsLeft() {
+				res.opJoinOptions = append(res.opJoinOptions, op.MustLeft())
+			} else {
+				res.opQueryOptions = append(res.opQueryOptions, op.MustRight())
+			}
 		case FilterOptionTypeRangeQuery:
 			res.rangeQueryOptions = append(res.rangeQueryOptions, any(opt).(RangeQueryOption))
 		case FilterOptionTypeFuzzyQuery:
 			res.fuzzyQueryOptions = append(res.fuzzyQueryOptions, any(opt).(FuzzyQueryOption))
+		case FilterOptionTypeGroup:
+			res.groupOptions = append(res.groupOptions, any(opt).(GroupOption))
 		default:
 			panic(fmt.Sprintf("Invalid filter option type %s", opt.GetFilterOptionType()))
 		}
@@ -481,6 +2472,53 @@ func parseFilterOptions(opts []FilterOption) filterOptions {
 	return res
 }
 
+// filterTables returns the tables of the columns filtered by opts, unqualified columns are ignored.
+func filterTables(opts []FilterOption) []string {
+	var (
+		parsed = parseFilterOptions(opts)
+		names  []ColumnName
+	)
+	for _, opt := range parsed.opJoinOptions {
+		names = append(names, opt.GetLeftColumnName(), opt.GetRightColumnName())
+	}
+	for _, opt := range parsed.opQueryOptions {
+		names = append(names, opt.GetColumnName())
+	}
+	for _, opt := range parsed.rangeQueryOptions {
+		names = append(names, opt.GetColumnName())
+	}
+	for _, opt := range parsed.fuzzyQueryOptions {
+		names = append(names, opt.GetColumnName())
+	}
+	tables := lo.FilterMap(names, func(cn ColumnName, _ int) (string, bool) { return cn.table, cn.table != "" })
+	for _, opt := range parsed.groupOptions {
+		tables = append(tables, filterTables(opt.GetFilterOptions())...)
+	}
+	return tables
+}
+
+// dialectQueryOp translates the query operator into the form supported by the dialect of db.
+func dialectQueryOp(db *gorm.DB, op QueryOp) string {
+	if op == OpEqNullSafe && db.Dialector.Name() != "mysql" {
+		return "IS NOT DISTINCT FROM"
+	}
+	return string(op)
+}
+
+// canonicalJSON returns an expression which converts the JSON text expr into the canonical form of the dialect of db.
+// Postgres and MySQL compare JSON values regardless of the formatting and the order of keys,
+// while SQLite only removes insignificant whitespaces.
+func canonicalJSON(db *gorm.DB, expr string) string {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return fmt.Sprintf("%s::jsonb", expr)
+	case "mysql":
+		return fmt.Sprintf("CAST(%s AS JSON)", expr)
+	default:
+		return fmt.Sprintf("json(%s)", expr)
+	}
+}
+
 func getColumnName(joined bool, opt ColumnNameGetter) string {
 	cn := opt.GetColumnName()
 	return lo.Ternary(joined, cn.Full(), cn.String())
@@ -491,6 +2529,106 @@ type serializer interface {
 	scan(ctx context.Context, dest, src any) error
 }
 
+// serializerChain applies its serializers from left to right when writing values and from right to left when
+// reading them. Each serializer but the first one reads into an intermediate value which is the source of the
+// serializer on its left.
+type serializerChain []serializer
+
+func (c serializerChain) value(ctx context.Context, v any) (any, error) {
+	for _, s := range c {
+		var err error
+		if v, err = s.value(ctx, v); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+func (c serializerChain) scan(ctx context.Context, dest, src any) error {
+	for i := len(c) - 1; i > 0; i-- {
+		var intermediate any
+		if err := c[i].scan(ctx, &intermediate, src); err != nil {
+			return err
+		}
+		src = intermediate
+	}
+	return c[0].scan(ctx, dest, src)
+}
+
+// gormSerializer adapts a serializer to the serializer interface of gorm.
+type gormSerializer struct {
+	serializer
+}
+
+func (s gormSerializer) Scan(ctx context.Context, field *gormschema.Field, dst reflect.Value, dbValue any) error {
+	fieldValue := reflect.New(field.FieldType)
+	if dbValue != nil {
+		if err := s.scan(ctx, fieldValue.Interface(), dbValue); err != nil {
+			return err
+		}
+	}
+	field.ReflectValueOf(ctx, dst).Set(fieldValue.Elem())
+	return nil
+}
+
+func (s gormSerializer) Value(ctx context.Context, _ *gormschema.Field, _ reflect.Value, fieldValue any) (any, error) {
+	return s.value(ctx, fieldValue)
+}
+
+// gzipSerializer compresses bytes and strings with gzip, the values read are decompressed bytes.
+type gzipSerializer struct{}
+
+func (gzipSerializer) value(_ context.Context, v any) (any, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		var err error
+		if v, err = valuer.Value(); err != nil {
+			return nil, err
+		}
+	}
+	var raw []byte
+	switch v := v.(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	case nil:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", v)
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(raw); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipSerializer) scan(_ context.Context, dest, src any) error {
+	var raw []byte
+	switch v := src.(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("unsupported value source %T", src)
+	}
+	r, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	decompressed, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return internalsql.ConvertAssign(dest, decompressed)
+}
+
 type jsonSerializer struct{}
 
 func (jsonSerializer) value(_ context.Context, v any) (any, error) {
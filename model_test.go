@@ -1,15 +1,21 @@
 package sqldb
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -352,6 +358,435 @@ func TestList(t *testing.T) {
 	}
 }
 
+func TestFind(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+
+	users, err := m.Query(m.Columns().Weight.LT(101)).Find(ctx, ListOptions{
+		Limit:       2,
+		SortOptions: []SortOption{m.Columns().Age.Sort(SortOrderAscending)},
+	})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{*u4, *u3}, users)
+}
+
+func TestListInto(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+
+	dest := make([]User, 0, 8)
+	total, err := m.Query(m.Columns().Weight.LT(101)).ListInto(ctx, ListOptions{
+		SortOptions: []SortOption{m.Columns().Age.Sort(SortOrderAscending)},
+	}, &dest)
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(3), total)
+	assert.EqualValues(t, []User{*u4, *u3, *u2}, dest)
+	assert.Equal(t, 8, cap(dest))
+}
+
+func TestDefaultSort(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db, WithDefaultSort(NewSortOption(NewColumnName("age"), SortOrderDescending)))
+
+	users, err := m.Query().Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{*u2, *u1, *u3, *u4}, users)
+
+	users, err = m.Query().Find(ctx, ListOptions{
+		SortOptions: []SortOption{m.Columns().ID.Sort(SortOrderAscending)},
+	})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{*u1, *u2, *u3, *u4}, users)
+}
+
+func TestTypedOpOptions(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+
+	users, err := m.Query(
+		cols.Age.GTETyped(30),
+		cols.Age.LTTyped(49),
+		cols.Address.NETyped("2824 Davis Court"),
+		cols.Status.EQTyped(Status{Occupation: "Teacher"}),
+	).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{*u3}, users)
+}
+
+func TestEqNullSafe(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+
+	_, err := m.Query(cols.ID.EQ(3)).Update(ctx, NewUpdateOption[any](cols.Address.ColumnName, nil))
+	assert.Nil(t, err, err)
+
+	users, err := m.Query(cols.Address.EqNullSafe(nil)).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Len(t, users, 1)
+	assert.Equal(t, uint64(3), users[0].ID.V)
+
+	addr := "4431 Jefferson Street"
+	users, err = m.Query(cols.Address.EqNullSafe(&addr)).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{*u4}, users)
+
+	users, err = m.Query(cols.Age.EqNullSafe(29)).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{*u4}, users)
+
+	// = NULL and != NULL never match, so they are rejected rather than silently matching no rows.
+	assert.PanicsWithValue(t, "failed to build query options for the column address: operator = can not be applied to NULL, use EqNullSafe instead",
+		func() { cols.Address.EQ(nil) })
+	assert.Panics(t, func() { cols.Address.NE(nil) })
+	assert.Panics(t, func() { cols.Address.EQ((*string)(nil)) })
+
+	// NULL matches NULL when comparing with a column reference as well.
+	users, err = m.Query(cols.Address.EqNullSafe(Ref(cols.Address))).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Len(t, users, 4)
+	users, err = m.Query(cols.Name.EqNullSafe(Ref(cols.Name))).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Len(t, users, 4)
+
+	relations := NewModel[Relation](db)
+	joined := Join(ctx, m, relations, NewJoinOptions(
+		[]ColumnNameGetter{cols.ID, relations.Columns().Name},
+		cols.Name.EqNullSafe(relations.Columns().UserName),
+	))
+	results, err := joined.Query().Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Len(t, results, 2)
+}
+
+func TestBuildWhere(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	cols := NewModel[User](db).Columns()
+
+	where, err := BuildWhere(db.Model(&User{}), []FilterOption{
+		cols.Age.GT(29),
+		cols.Weight.In([]uint{45, 100, 107}),
+	})
+	assert.Nil(t, err, err)
+	var ids []uint64
+	assert.Nil(t, where.Order("id").Pluck("id", &ids).Error)
+	assert.Equal(t, []uint64{1, 3}, ids)
+}
+
+func TestExprValue(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+
+	users, err := m.Query(cols.Weight.GT(gorm.Expr("age * ?", 2))).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{*u1, *u4}, users)
+}
+
+func TestCanonicalJSONEquality(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+
+	assert.Nil(t, db.Exec(`UPDATE users SET status = '{ "Occupation" :  "Teacher" }' WHERE id = 3`).Error)
+	user, err := m.Query(m.Columns().Status.EQ(Status{Occupation: "Teacher"})).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, *u3, user)
+
+	users, err := m.Query(m.Columns().Status.NE(Status{Occupation: "Teacher"})).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{*u1, *u2, *u4}, users)
+}
+
+func TestJSONBContains(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+
+	_, err := m.Query(m.Columns().Status.JSONBContains(map[string]any{"Occupation": "Teacher"})).Find(ctx, ListOptions{})
+	assert.ErrorContains(t, err, "not supported")
+}
+
+func TestColumnRef(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+
+	users, err := m.Query(cols.Weight.LT(Ref(cols.Age))).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Empty(t, users)
+
+	users, err = m.Query(cols.Weight.GT(Ref(cols.Age)), cols.Weight.LT(80)).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{*u2, *u3}, users)
+}
+
+func TestDistinctOn(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+
+	_, err := m.Query().DistinctOn(m.Columns().Age).Find(ctx, ListOptions{})
+	assert.ErrorContains(t, err, "not supported")
+
+	// the statements for Postgres are checked in a dry run, the total counts the distinct rows.
+	pg, err := gorm.Open(postgresDialector{sqlite.Open(dbName)}, &gorm.Config{DryRun: true})
+	assert.Nil(t, err, err)
+	var statements []string
+	assert.Nil(t, pg.Callback().Query().After("gorm:query").Register("record", func(db *gorm.DB) {
+		statements = append(statements, db.Statement.SQL.String())
+	}))
+	m = NewModel[User](pg)
+	_, _, err = m.Query(m.Columns().Age.GT(20)).DistinctOn(m.Columns().Age).List(ctx, ListOptions{Limit: 2})
+	assert.Nil(t, err, err)
+	// the subquery is built by a statement of its own before the count.
+	assert.Equal(t, []string{
+		"SELECT DISTINCT ON (age) * FROM `users` WHERE age > ? AND `users`.`deleted_at` IS NULL",
+		"SELECT count(*) FROM (SELECT DISTINCT ON (age) * FROM `users` WHERE age > ? AND `users`.`deleted_at` IS NULL) AS sqldb_distinct",
+		"SELECT DISTINCT ON (age) * FROM `users` WHERE age > ? AND `users`.`deleted_at` IS NULL LIMIT 2",
+	}, statements)
+	_, _, err = m.Query().DistinctOn(m.Columns().Age).List(ctx, ListOptions{WindowTotal: true})
+	assert.EqualError(t, err, "DISTINCT ON is not supported with the window total")
+}
+
+// postgresDialector renders statements of SQLite under the name of Postgres, so that the statements for Postgres
+// can be checked in dry runs.
+type postgresDialector struct {
+	gorm.Dialector
+}
+
+func (postgresDialector) Name() string {
+	return "postgres"
+}
+
+func TestExport(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+	opts := ListOptions{SortOptions: []SortOption{cols.ID.Sort(SortOrderAscending)}}
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, m.Query(cols.ID.In([]uint64{1, 3})).Export(ctx, opts, buf, ExportFormatNDJSON))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+	var user User
+	assert.Nil(t, json.Unmarshal([]byte(lines[1]), &user))
+	assert.Equal(t, u3.Name.V, user.Name.V)
+	assert.Equal(t, u3.Status.V, user.Status.V)
+
+	buf.Reset()
+	assert.Nil(t, m.Query(cols.ID.In([]uint64{1, 3})).Export(ctx, opts, buf, ExportFormatCSV))
+	records, err := csv.NewReader(buf).ReadAll()
+	assert.Nil(t, err, err)
+	assert.Len(t, records, 3)
+	assert.Equal(t, []string{"id", "user_name", "age", "address", "status", "embedded_weight"}, records[0][:6])
+	assert.Equal(t, []string{"3", "Sebastian Turner", "30", "Michigan, Billings", `{"Occupation":"Teacher"}`, "45"}, records[2][:6])
+
+	buf.Reset()
+	selected := ListOptions{Columns: []ColumnNameGetter{cols.Name, cols.ID}, SortOptions: opts.SortOptions}
+	assert.Nil(t, m.Query(cols.ID.In([]uint64{1, 3})).Export(ctx, selected, buf, ExportFormatCSV))
+	records, err = csv.NewReader(buf).ReadAll()
+	assert.Nil(t, err, err)
+	assert.Equal(t, [][]string{{"user_name", "id"}, {"William K Turner", "1"}, {"Sebastian Turner", "3"}}, records)
+
+	buf.Reset()
+	assert.Nil(t, m.Query(cols.ID.EQ(uint64(100))).Export(ctx, selected, buf, ExportFormatCSV))
+	records, err = csv.NewReader(buf).ReadAll()
+	assert.Nil(t, err, err)
+	assert.Equal(t, [][]string{{"user_name", "id"}}, records)
+
+	assert.NotNil(t, m.Query().Export(ctx, opts, buf, "xml"))
+}
+
+func TestMarshal(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+
+	for _, strategy := range []JSONKeyStrategy{JSONKeyFieldName, JSONKeyColumnName, JSONKeyTag} {
+		m := NewModel[User](db, WithJSONKeyStrategy(strategy))
+		raw, err := m.Marshal(*u1)
+		assert.Nil(t, err, err)
+		var object map[string]any
+		assert.Nil(t, json.Unmarshal(raw, &object))
+		if strategy == JSONKeyFieldName {
+			assert.Equal(t, "William K Turner", object["Name"])
+		} else {
+			assert.Equal(t, "William K Turner", object["user_name"])
+			assert.Equal(t, "2824 Davis Court", object["address"])
+			assert.EqualValues(t, 107, object["embedded_weight"])
+		}
+		var user User
+		assert.Nil(t, m.Unmarshal(raw, &user))
+		assert.Equal(t, *u1, user)
+	}
+}
+
+type Credential struct {
+	ID    Column[uint64] `gorm:"column:id;primaryKey" json:"id"`
+	Name  Column[string] `json:"name"`
+	Token Column[string] `json:"-"`
+	Dash  Column[string] `json:"-,"`
+}
+
+func TestMarshalOmittedFields(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+
+	m := NewModel[Credential](db, WithJSONKeyStrategy(JSONKeyTag))
+	raw, err := m.Marshal(Credential{ID: NewColumn[uint64](1), Name: NewColumn("a"), Token: NewColumn("secret"), Dash: NewColumn("d")})
+	assert.Nil(t, err, err)
+	assert.JSONEq(t, `{"id":1,"name":"a","-":"d"}`, string(raw))
+
+	var c Credential
+	assert.Nil(t, m.Unmarshal(raw, &c))
+	assert.Equal(t, Credential{ID: NewColumn[uint64](1), Name: NewColumn("a"), Dash: NewColumn("d")}, c)
+
+	c = Credential{}
+	assert.Nil(t, m.Unmarshal([]byte(`{"id":2,"name":"b","-":"leak","token":"leak"}`), &c))
+	assert.Equal(t, "", c.Token.V)
+	assert.Equal(t, "leak", c.Dash.V)
+}
+
+func TestTimeInUTC(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db, WithTimeInUTC())
+
+	u := NewUser(5, "Ryan Reed", 20, "", 60, "Student", "ryan.reed@gmail.com")
+	u.CreatedAt = NewColumn(time.Date(2022, 10, 1, 8, 0, 0, 0, time.FixedZone("UTC+8", 8*60*60)))
+	assert.Nil(t, m.Create(ctx, u))
+
+	user, err := m.Query(m.Columns().ID.EQ(5)).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, time.UTC, user.CreatedAt.V.Location())
+	assert.True(t, u.CreatedAt.V.Equal(user.CreatedAt.V))
+
+	users, err := m.Query(m.Columns().ID.EQ(5)).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Equal(t, time.UTC, users[0].CreatedAt.V.Location())
+}
+
+type Product struct {
+	ID    Column[uint64]          `gorm:"column:id;primaryKey"`
+	Price Column[decimal.Decimal] `gorm:"type:decimal(20,8)"`
+}
+
+func TestDecimalColumn(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	assert.Nil(t, db.AutoMigrate(Product{}))
+	m := NewModel[Product](db)
+	cols := m.Columns()
+
+	for i, price := range []string{"9.5", "10.25", "100"} {
+		assert.Nil(t, m.Create(ctx, &Product{ID: NewColumn(uint64(i + 1)), Price: NewColumn(decimal.RequireFromString(price))}))
+	}
+	products, err := m.Query(cols.Price.GT(decimal.NewFromInt(10))).Find(ctx, ListOptions{
+		SortOptions: []SortOption{cols.Price.Sort(SortOrderAscending)},
+	})
+	assert.Nil(t, err, err)
+	assert.Len(t, products, 2)
+	assert.True(t, decimal.RequireFromString("10.25").Equal(products[0].Price.V))
+
+	_, err = m.Query(cols.ID.EQ(1)).Update(ctx, cols.Price.Update(decimal.RequireFromString("1000.125")))
+	assert.Nil(t, err, err)
+	product, err := m.Query(cols.Price.GTETyped(decimal.NewFromInt(1000))).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(1), product.ID.V)
+	assert.True(t, decimal.RequireFromString("1000.125").Equal(product.Price.V))
+}
+
+func TestStatementTimeout(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+
+	m := NewModel[User](db, WithStatementTimeout(time.Nanosecond))
+	_, err := m.Query().Find(ctx, ListOptions{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	m = NewModel[User](db, WithStatementTimeout(time.Minute))
+	users, err := m.Query().Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Len(t, users, 4)
+}
+
+type Owner struct {
+	ID   Column[uint64] `gorm:"column:id;primaryKey"`
+	Name Column[string]
+	Pets []Pet `gorm:"foreignKey:OwnerID"`
+}
+
+type Pet struct {
+	ID      Column[uint64] `gorm:"column:id;primaryKey"`
+	OwnerID Column[uint64]
+	Name    Column[string]
+}
+
+func TestPreload(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	assert.Nil(t, db.AutoMigrate(Owner{}, Pet{}))
+
+	var queries int
+	assert.Nil(t, db.Callback().Query().After("gorm:query").Register("test:count_queries", func(*gorm.DB) {
+		queries++
+	}))
+	owners, pets := NewModel[Owner](db), NewModel[Pet](db)
+	for i := uint64(1); i <= 10; i++ {
+		assert.Nil(t, owners.Create(ctx, &Owner{ID: NewColumn(i), Name: NewColumn(fmt.Sprintf("owner%d", i))}))
+		for j := uint64(0); j < i%3; j++ {
+			assert.Nil(t, pets.Create(ctx, &Pet{ID: NewColumn(i*10 + j), OwnerID: NewColumn(i), Name: NewColumn("pet")}))
+		}
+	}
+
+	for _, limit := range []uint64{1, 5, 10} {
+		queries = 0
+		results, err := owners.Query().Preload("Pets").Find(ctx, ListOptions{Limit: limit})
+		assert.Nil(t, err, err)
+		assert.Len(t, results, int(limit))
+		assert.Equal(t, 2, queries)
+		for _, owner := range results {
+			assert.Len(t, owner.Pets, int(owner.ID.V%3))
+		}
+	}
+
+	queries = 0
+	owner, err := owners.Query(owners.Columns().ID.EQ(5)).Preload("Pets").Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Len(t, owner.Pets, 2)
+	assert.Equal(t, 2, queries)
+}
+
+func TestRows(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+
+	rows, err := m.Query(m.Columns().Age.LT(40)).Rows(ctx, ListOptions{
+		SortOptions: []SortOption{m.Columns().Age.Sort(SortOrderAscending)},
+	})
+	assert.Nil(t, err, err)
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var user User
+		assert.Nil(t, db.ScanRows(rows, &user))
+		names = append(names, user.Name.V)
+	}
+	assert.Nil(t, rows.Err())
+	assert.Equal(t, []string{u4.Name.V, u3.Name.V}, names)
+}
+
 func TestGet(t *testing.T) {
 	db, clean := initDB(t)
 	defer clean()
@@ -586,17 +1021,2138 @@ func TestUserRelationJoin(t *testing.T) {
 	}
 }
 
-func removeColumnNames[T any](v T) T {
-	iterateFields(&v, func(fieldAddr reflect.Value, path []reflect.StructField) (bool, error) {
-		if setter, ok := fieldAddr.Interface().(columnNameSetter); ok {
-			setter.setColumnName("", "")
-			return false, nil
-		}
-		return true, nil
-	})
-	return v
+func TestFuzzyInCaseSensitive(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+
+	users, err := m.Query(cols.Name.FuzzyIn([]string{"turner"})).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{*u1, *u3}, users)
+
+	users, err = m.Query(cols.Name.FuzzyInCaseSensitive([]string{"turner"})).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Empty(t, users)
+
+	users, err = m.Query(cols.Name.FuzzyInCaseSensitive([]string{"Sebastian T", "Vera"})).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{*u3, *u4}, users)
 }
 
-func removeListColumnNames[T any](vs []T) []T {
-	return lo.Map(vs, func(v T, _ int) T { return removeColumnNames(v) })
+func TestFuzzyInNonString(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+	assert.PanicsWithValue(t, "fuzzy queries are only supported by string columns, the column created_at is of type time.Time",
+		func() { cols.CreatedAt.FuzzyIn([]time.Time{time.Now()}) })
+	assert.Panics(t, func() { cols.Age.FuzzyInCaseSensitive([]int{4}) })
+
+	users, err := m.Query(cols.Address.FuzzyIn([]string{"Street"})).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Len(t, users, 2)
+}
+
+func TestGroupOptions(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+
+	for index, c := range []struct {
+		queries []FilterOption
+		expect  []User
+	}{
+		{
+			queries: []FilterOption{Or(cols.Age.GT(45), cols.Name.FuzzyIn([]string{"Vera"}))},
+			expect:  []User{*u1, *u2, *u4},
+		},
+		{
+			queries: []FilterOption{Not(cols.Age.GT(45))},
+			expect:  []User{*u3, *u4},
+		},
+		{
+			queries: []FilterOption{Not(Or(cols.ID.EQ(uint64(1)), cols.ID.EQ(uint64(2)))), cols.Age.LT(30)},
+			expect:  []User{*u4},
+		},
+		{
+			queries: []FilterOption{Or(And(cols.Age.GT(40), cols.Weight.LT(100)), Not(cols.ID.In([]uint64{1, 2, 4})))},
+			expect:  []User{*u2, *u3},
+		},
+		{
+			queries: []FilterOption{Not(cols.Extra.Email.FuzzyIn([]string{"yahoo", "facebook"}), cols.Age.GT(0))},
+			expect:  []User{*u4},
+		},
+	} {
+		users, err := m.Query(c.queries...).Find(ctx, ListOptions{})
+		assert.Nil(t, err, err)
+		assert.EqualValues(t, c.expect, users, "case %d", index)
+	}
+}
+
+func TestValuesListIn(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db, WithValuesListIn(2))
+	cols := m.Columns()
+
+	var statements []string
+	assert.Nil(t, db.Callback().Query().After("gorm:query").Register("test:record_sql", func(db *gorm.DB) {
+		statements = append(statements, db.Statement.SQL.String())
+	}))
+	defer db.Callback().Query().Remove("test:record_sql")
+
+	users, err := m.Query(cols.ID.In([]uint64{1, 3, 4}), cols.Age.NotIn([]int{29})).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{*u1, *u3}, users)
+	assert.Contains(t, statements[0], "id IN (VALUES (?),(?),(?)) AND age NOT IN (?)")
+
+	users, err = m.Query(cols.Status.In([]Status{{Occupation: "Teacher"}, {Occupation: "Health Educator"}, {Occupation: "Unknown"}})).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{*u1, *u3}, users)
+}
+
+func TestUpsertInBatches(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+
+	updated, created := *u1, NewUser(5, "Lea Morgan", 33, "12 Hill Road", 60, "Pilot", "lea@example.com")
+	updated.Age, updated.Name = NewColumn(50), NewColumn("Renamed")
+	assert.Nil(t, m.UpsertInBatches(ctx, []*User{&updated, created}, []ColumnNameGetter{cols.ID}, []ColumnNameGetter{cols.Age}, 1))
+
+	users, err := m.Query(cols.ID.In([]uint64{1, 5})).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	expected := *u1
+	expected.Age = NewColumn(50)
+	assert.EqualValues(t, []User{expected, *created}, users)
+
+	ignored := *u2
+	ignored.Age = NewColumn(1)
+	assert.Nil(t, m.UpsertInBatches(ctx, []*User{&ignored}, []ColumnNameGetter{cols.ID}, nil, 10))
+	user, err := m.Query(cols.ID.EQ(uint64(2))).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, *u2, user)
+}
+
+func TestApplyScopes(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+
+	var (
+		adults  Scope = func() []FilterOption { return []FilterOption{cols.Age.GTE(30)} }
+		turners Scope = func() []FilterOption { return []FilterOption{cols.Name.FuzzyIn([]string{"Turner"})} }
+	)
+	base := m.Query(cols.ID.NE(uint64(3)))
+	users, err := base.Apply(adults, turners).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{*u1}, users)
+
+	users, err = base.Apply(adults).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{*u1, *u2}, users)
+}
+
+func TestUpdateNull(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+
+	updated, err := m.Query(cols.ID.EQ(uint64(1))).Update(ctx, cols.Address.UpdateNull(), cols.Status.UpdateNull())
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(1), updated)
+	updated, err = m.Query(cols.ID.EQ(uint64(2))).Update(ctx, cols.Address.Update("New Street"))
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(1), updated)
+
+	users, err := m.Query(cols.ID.In([]uint64{1, 2})).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	expected1, expected2 := *u1, *u2
+	expected1.Address, expected1.Status = PtrColumn[string]{}, Column[Status]{}
+	expected2.Address = NewPtrColumn("New Street")
+	assert.EqualValues(t, []User{expected1, expected2}, users)
+
+	var nulls int64
+	assert.Nil(t, db.Table("users").Where("status IS NULL AND address IS NULL").Count(&nulls).Error)
+	assert.Equal(t, int64(1), nulls)
+}
+
+func TestSafeDestructive(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db, WithSafeDestructive())
+	cols := m.Columns()
+
+	_, err := m.Query().Update(ctx, cols.Age.Update(1))
+	assert.ErrorIs(t, err, ErrMissingFilters)
+	assert.ErrorIs(t, m.Query().Delete(ctx), ErrMissingFilters)
+
+	updated, err := m.Query().AllowGlobalUpdate().Update(ctx, cols.Age.Update(1))
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(4), updated)
+	assert.Nil(t, m.Query(cols.ID.EQ(uint64(1))).Delete(ctx))
+	assert.Nil(t, m.Query().AllowGlobalUpdate().Delete(ctx))
+
+	users, err := m.Query().Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Empty(t, users)
+}
+
+func TestPrimaryReads(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	replica, err := gorm.Open(sqlite.Open("replica.db"), &gorm.Config{})
+	assert.Nil(t, err, err)
+	defer os.Remove("replica.db")
+	assert.Nil(t, replica.AutoMigrate(User{}))
+	assert.Nil(t, NewModel[User](replica).Create(ctx, u1))
+
+	m := NewModel[User](db, WithReadReplicas(replica))
+	users, err := m.Query().Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{*u1}, users)
+
+	users, err = m.Query().Find(WithPrimaryReads(ctx), ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{*u1, *u2, *u3, *u4}, users)
+
+	assert.Nil(t, NewTransactionFunc(db)(ctx, func(ctx context.Context) error {
+		_, total, err := m.Query().List(ctx, ListOptions{})
+		assert.Equal(t, uint64(4), total)
+		return err
+	}))
+}
+
+func TestUpsertIndexPredicate(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	assert.Nil(t, db.Exec("CREATE UNIQUE INDEX idx_users_name ON users(user_name) WHERE deleted_at IS NULL").Error)
+	m := NewModel[User](db)
+	cols := m.Columns()
+
+	renamed := NewUser(10, "Vera Crawford", 31, "4431 Jefferson Street", 100, "Collage student", "jake.andrews@163.com")
+	conflict, update := []ColumnNameGetter{cols.Name}, []ColumnNameGetter{cols.Age}
+	assert.NotNil(t, m.Upsert(ctx, renamed, conflict, update))
+	assert.Nil(t, m.Upsert(ctx, renamed, conflict, update, WithIndexPredicate("deleted_at IS NULL")))
+
+	user, err := m.Query(cols.Name.EQ("Vera Crawford")).Get(ctx)
+	assert.Nil(t, err, err)
+	expected := *u4
+	expected.Age = NewColumn(31)
+	assert.Equal(t, expected, user)
+}
+
+func TestUpsertUpdateIf(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+	conflict, update := []ColumnNameGetter{cols.ID}, []ColumnNameGetter{cols.Age, cols.CreatedAt}
+
+	stale := *u1
+	stale.Age, stale.CreatedAt = NewColumn(99), NewColumn(u1.CreatedAt.V.Add(-time.Hour))
+	assert.Nil(t, m.Upsert(ctx, &stale, conflict, update, WithUpdateIf(cols.CreatedAt, OpGt)))
+	user, err := m.Query(cols.ID.EQ(uint64(1))).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, *u1, user)
+
+	fresh := *u1
+	fresh.Age, fresh.CreatedAt = NewColumn(47), NewColumn(u1.CreatedAt.V.Add(time.Hour))
+	assert.Nil(t, m.UpsertInBatches(ctx, []*User{&fresh, &stale}, conflict, update, 1, WithUpdateIf(cols.CreatedAt, OpGt)))
+	user, err = m.Query(cols.ID.EQ(uint64(1))).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, fresh, user)
+
+	assert.Panics(t, func() { WithUpdateIf(cols.CreatedAt, "LIKE") })
+	assert.Panics(t, func() { WithUpdateIf(cols.CreatedAt, OpJSONContains) })
+	assert.Panics(t, func() { WithUpdateIf(cols.Age, OpHasFlag) })
+	assert.Panics(t, func() { WithUpdateIf(cols.Address, OpEqNullSafe) })
+}
+
+func TestCountBy(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+	_, err := m.Query(cols.ID.EQ(uint64(4))).Update(ctx, cols.Age.Update(30))
+	assert.Nil(t, err, err)
+
+	counts, err := m.Query().CountBy(ctx, cols.Age)
+	assert.Nil(t, err, err)
+	assert.Equal(t, map[any]uint64{int64(30): 2, int64(46): 1, int64(49): 1}, counts)
+
+	counts, err = m.Query(cols.Age.LT(40)).CountBy(ctx, cols.Name, cols.ID.NE(uint64(3)))
+	assert.Nil(t, err, err)
+	assert.Equal(t, map[any]uint64{"Vera Crawford": 1}, counts)
+
+	_, err = m.Query(cols.ID.EQ(uint64(3))).Update(ctx, cols.Address.UpdateNull())
+	assert.Nil(t, err, err)
+	counts, err = m.Query().DistinctCountBy(ctx, cols.Age, cols.Address)
+	assert.Nil(t, err, err)
+	assert.Equal(t, map[any]uint64{int64(30): 1, int64(46): 1, int64(49): 1}, counts)
+}
+
+func TestReport(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+	_, err := m.Query(cols.ID.EQ(uint64(4))).Update(ctx, cols.Age.Update(30))
+	assert.Nil(t, err, err)
+
+	type AgeStat struct {
+		Age       Column[int]
+		Count     Column[uint64]
+		MaxWeight Column[uint]
+	}
+	r := NewReport[User, AgeStat](m, cols.ID.NE(uint64(2)))
+	rc := r.Columns()
+	stats, err := r.GroupBy(cols.Age).
+		Aggregate(
+			Aggregate{Func: AggregateCount, As: rc.Count},
+			Aggregate{Func: AggregateMax, Column: cols.Weight, As: rc.MaxWeight},
+		).
+		Find(ctx, ListOptions{SortOptions: []SortOption{rc.Age.Sort(SortOrderAscending)}})
+	assert.Nil(t, err, err)
+	assert.Equal(t, []int{30, 46}, lo.Map(stats, func(s AgeStat, _ int) int { return s.Age.V }))
+	assert.Equal(t, []uint64{2, 1}, lo.Map(stats, func(s AgeStat, _ int) uint64 { return s.Count.V }))
+	assert.Equal(t, []uint{100, 107}, lo.Map(stats, func(s AgeStat, _ int) uint { return s.MaxWeight.V }))
+
+	stats, err = r.GroupBy(cols.Age).
+		Aggregate(Aggregate{Func: AggregateCount, As: rc.Count}).
+		Having(rc.Count.GT(uint64(1))).
+		Find(ctx, ListOptions{Limit: 1})
+	assert.Nil(t, err, err)
+	assert.Len(t, stats, 1)
+	assert.Equal(t, 30, stats[0].Age.V)
+
+	stats, err = r.GroupBy(cols.Age).
+		Aggregate(Aggregate{Func: AggregateMax, Column: cols.Weight, As: rc.MaxWeight}).
+		HavingAggregate(Count(nil).EQ(1), Max(cols.Weight).GT(100)).
+		Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Equal(t, []int{46}, lo.Map(stats, func(s AgeStat, _ int) int { return s.Age.V }))
+	assert.Panics(t, func() { Count(cols.ID).GT(nil) })
+
+	// aggregates over no rows are NULL except COUNT.
+	stats, err = NewReport[User, AgeStat](m, cols.ID.GT(uint64(10))).
+		Aggregate(Aggregate{Func: AggregateCount, As: rc.Count}, Aggregate{Func: AggregateMax, Column: cols.Weight, As: rc.MaxWeight}).
+		Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Equal(t, []AgeStat{{}}, removeListColumnNames(stats))
+
+	// NULL is only scanned as the zero value in reports.
+	var sum Column[float64]
+	assert.NotNil(t, db.Raw("SELECT SUM(age) FROM users WHERE id > 10").Row().Scan(&sum))
+
+	_, err = r.Find(ctx, ListOptions{})
+	assert.NotNil(t, err)
+}
+
+func TestListSoftDeleted(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+	assert.Nil(t, m.Query(cols.ID.In([]uint64{1, 3})).Delete(ctx))
+
+	for _, opts := range []ListOptions{
+		{},
+		{SortOptions: []SortOption{cols.Age.Sort(SortOrderDescending)}},
+		{WindowTotal: true},
+	} {
+		users, total, err := m.Query().List(ctx, opts)
+		assert.Nil(t, err, err)
+		assert.Equal(t, uint64(2), total)
+		assert.Len(t, users, int(total))
+	}
+
+	users, total, err := m.Query(cols.Age.GT(40)).List(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(1), total)
+	assert.Equal(t, []User{*u2}, users)
+}
+
+func TestAsOf(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	asOf := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	toSQL := func(m Model[User]) string {
+		e := m.Query(m.Columns().Age.GT(40)).(executor[User])
+		db, err := e.listDB(ctx, ListOptions{AsOf: asOf})
+		assert.Nil(t, err, err)
+		return db.ToSQL(func(tx *gorm.DB) *gorm.DB { return tx.Find(&[]User{}) })
+	}
+	assert.NotContains(t, toSQL(NewModel[User](db)), "AS OF SYSTEM TIME")
+	assert.Contains(t, toSQL(NewModel[User](db, WithAsOfSystemTime())),
+		"FROM `users` AS OF SYSTEM TIME '2024-01-02 03:04:05+00:00' WHERE")
+
+	// AsOf is ignored by models without the option
+	users, relations := NewModel[User](db), NewModel[Relation](db)
+	found, err := users.Query().Find(ctx, ListOptions{AsOf: asOf})
+	assert.Nil(t, err, err)
+	assert.Len(t, found, 4)
+	_, total, err := users.Query().List(ctx, ListOptions{AsOf: asOf, WindowTotal: true})
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(4), total)
+	joined := Join(ctx, users, relations, NewJoinOptions(users.ColumnNames(), users.Columns().Name.EQ(relations.Columns().UserName)))
+	_, total, err = joined.Query().List(ctx, ListOptions{AsOf: asOf})
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(2), total)
+}
+
+func TestIndexHint(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	assert.Nil(t, db.Exec("CREATE INDEX idx_users_age ON users(age)").Error)
+	m := NewModel[User](db)
+	cols := m.Columns()
+
+	users, total, err := m.Query(cols.Age.GT(40)).WithIndexHint("idx_users_age").List(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(2), total)
+	assert.Len(t, users, 2)
+	_, err = m.Query(cols.Age.GT(40)).WithIndexHint("idx_missing").Find(ctx, ListOptions{})
+	assert.ErrorContains(t, err, "no such index")
+
+	users, err = NewModel[User](db, WithTableAlias("u")).Query(cols.Age.GT(40)).WithIndexHint("idx_users_age").Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Len(t, users, 2)
+}
+
+func TestInTransaction(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	users, relations := NewModel[User](db), NewModel[Relation](db)
+
+	errRollback := errors.New("rollback")
+	err := InTransaction(ctx, db, func(ctx context.Context) error {
+		if _, err := users.Query(users.Columns().ID.EQ(uint64(1))).Update(ctx, users.Columns().Age.Update(60)); err != nil {
+			return err
+		}
+		if err := relations.Query(relations.Columns().ID.EQ(uint64(1))).Delete(ctx); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	assert.ErrorIs(t, err, errRollback)
+	user, err := users.Query(users.Columns().ID.EQ(uint64(1))).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, 46, user.Age.V)
+	_, total, err := relations.Query().List(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(3), total)
+
+	assert.Nil(t, InTransaction(ctx, db, func(ctx context.Context) error {
+		_, err := users.Query(users.Columns().ID.EQ(uint64(1))).Update(ctx, users.Columns().Age.Update(60))
+		return err
+	}))
+	user, err = users.Query(users.Columns().ID.EQ(uint64(1))).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, 60, user.Age.V)
+}
+
+func TestScanError(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	assert.Nil(t, db.Exec("UPDATE users SET age = 'old' WHERE id = 1").Error)
+	users, relations := NewModel[User](db), NewModel[Relation](db)
+
+	_, err := users.Query(users.Columns().ID.EQ(uint64(1))).Get(ctx)
+	assert.ErrorContains(t, err, `name "age": failed to convert string into int`)
+
+	joined := Join(ctx, users, relations, NewJoinOptions(
+		[]ColumnNameGetter{users.Columns().Age, relations.Columns().Name},
+		users.Columns().Name.EQ(relations.Columns().UserName),
+	))
+	_, err = joined.Query(joined.Columns().Left.ID.EQ(uint64(1))).Get(ctx)
+	assert.ErrorContains(t, err, "of the column users.age into field Left.Age: failed to convert string into int")
+}
+
+func TestDescribeFilters(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+	descriptors := m.Query(
+		Or(cols.Age.GT(40), cols.Name.FuzzyIn([]string{"Vera"})),
+		cols.ID.In([]uint64{1, 2}),
+		cols.Name.EQ("William"),
+	).DescribeFilters()
+	assert.Equal(t, []FilterDescriptor{
+		{Type: FilterOptionTypeOpQuery, Column: "users.user_name", Op: "=", Value: "William"},
+		{Type: FilterOptionTypeRangeQuery, Column: "users.id", Op: "IN", Values: []any{uint64(1), uint64(2)}},
+		{Type: FilterOptionTypeGroup, Op: "OR", Filters: []FilterDescriptor{
+			{Type: FilterOptionTypeOpQuery, Column: "users.age", Op: ">", Value: 40},
+			{Type: FilterOptionTypeFuzzyQuery, Column: "users.user_name", Op: "LIKE", Values: []any{"Vera"}},
+		}},
+	}, descriptors)
+
+	data, err := json.Marshal(descriptors[0])
+	assert.Nil(t, err, err)
+	assert.JSONEq(t, `{"type":"OpQuery","column":"users.user_name","op":"=","value":"William"}`, string(data))
+}
+
+func TestMaxByMinBy(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+
+	user, err := m.Query().MaxBy(ctx, cols.Age)
+	assert.Nil(t, err, err)
+	assert.Equal(t, *u2, user)
+	user, err = m.Query(cols.Name.FuzzyIn([]string{"Turner"})).MinBy(ctx, cols.Age)
+	assert.Nil(t, err, err)
+	assert.Equal(t, *u3, user)
+	_, err = m.Query(cols.Age.GT(100)).MaxBy(ctx, cols.Age)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestUpdateSubquery(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m, relations := NewModel[User](db), NewModel[Relation](db)
+	cols, rc := m.Columns(), relations.Columns()
+
+	updated, err := m.Query(cols.ID.In([]uint64{1, 2, 4})).Update(ctx,
+		cols.Weight.Update(NewSubquery(relations.Query(rc.UserName.EQ(Ref(cols.Name))), AggregateMax, rc.Age)))
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(3), updated)
+	users, err := m.Query().Find(ctx, ListOptions{SortOptions: []SortOption{cols.ID.Sort(SortOrderAscending)}})
+	assert.Nil(t, err, err)
+	assert.Equal(t, []uint{30, 0, 45, 20}, lo.Map(users, func(u User, _ int) uint { return u.Weight.V }))
+
+	_, err = m.Query(cols.ID.EQ(uint64(3))).Update(ctx,
+		cols.Age.Update(NewSubquery(relations.Query(rc.ID.EQ(uint64(3))), "", rc.Age)))
+	assert.Nil(t, err, err)
+	user, err := m.Query(cols.ID.EQ(uint64(3))).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, 40, user.Age.V)
+}
+
+func TestUpdateDiff(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+
+	old, err := m.Query(cols.ID.EQ(uint64(1))).Get(ctx)
+	assert.Nil(t, err, err)
+	updated := old
+	updated.Age = NewColumn(47)
+	updated.Address = PtrColumn[string]{}
+	updated.Status = NewColumn(Status{Occupation: "Retired"})
+	n, err := m.UpdateDiff(ctx, &old, &updated)
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(1), n)
+
+	user, err := m.Query(cols.ID.EQ(uint64(1))).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, 47, user.Age.V)
+	assert.Nil(t, user.Address.V)
+	assert.Equal(t, "Retired", user.Status.V.Occupation)
+	assert.Equal(t, old.Name, user.Name)
+
+	n, err = m.UpdateDiff(ctx, &user, &user)
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(0), n)
+}
+
+type Tag struct {
+	gorm.Model
+	Name Column[string]
+}
+
+func TestUpdateDiffPlainPrimaryKey(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	assert.Nil(t, db.AutoMigrate(Tag{}))
+	defer db.Migrator().DropTable(Tag{})
+	m := NewModel[Tag](db)
+	cols := m.Columns()
+
+	for _, name := range []string{"a", "b", "c"} {
+		assert.Nil(t, m.Create(ctx, &Tag{Name: NewColumn(name)}))
+	}
+	old, err := m.Query(cols.Name.EQ("b")).Get(ctx)
+	assert.Nil(t, err, err)
+	updated := old
+	updated.Name = NewColumn("renamed")
+	n, err := m.UpdateDiff(ctx, &old, &updated)
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(1), n)
+
+	tags, err := m.Query().Find(ctx, ListOptions{SortOptions: []SortOption{NewSortOption(NewColumnName("id"), SortOrderAscending)}})
+	assert.Nil(t, err, err)
+	assert.Equal(t, []string{"a", "renamed", "c"}, lo.Map(tags, func(tag Tag, _ int) string { return tag.Name.V }))
+}
+
+func TestListColumns(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+
+	users, total, err := m.Query(cols.Age.GT(40)).List(ctx, ListOptions{
+		Columns:     []ColumnNameGetter{cols.ID, cols.Name},
+		SortOptions: []SortOption{cols.ID.Sort(SortOrderAscending)},
+	})
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(2), total)
+	assert.Equal(t, []User{{ID: u1.ID, Name: u1.Name}, {ID: u2.ID, Name: u2.Name}}, users)
+
+	relations := NewModel[Relation](db)
+	joined := Join(ctx, m, relations, NewJoinOptions(
+		[]ColumnNameGetter{cols.ID, cols.Age, relations.Columns().ID},
+		cols.Name.EQ(relations.Columns().UserName),
+	))
+	results, err := joined.Query().Find(ctx, ListOptions{
+		Columns:     []ColumnNameGetter{joined.Columns().Left.ID, joined.Columns().Right.Name},
+		SortOptions: []SortOption{joined.Columns().Left.ID.Sort(SortOrderAscending)},
+	})
+	assert.Nil(t, err, err)
+	assert.Equal(t, [][3]any{{uint64(1), 0, "relation2"}, {uint64(4), 0, "relation1"}}, lo.Map(results, func(r JoinedEntity[User, Relation], _ int) [3]any {
+		return [3]any{r.Left.ID.V, r.Left.Age.V, r.Right.Name.V}
+	}))
+}
+
+func TestWithDB(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	assert.Nil(t, db.Table("archived_users").AutoMigrate(User{}))
+	defer db.Migrator().DropTable("archived_users")
+	m := NewModel[User](db)
+	archived := m.WithDB(db.Table("archived_users"))
+
+	assert.Nil(t, archived.Create(ctx, NewUser(5, "Archived", 80, "", 60, "Retired", "")))
+	users, err := archived.Query(archived.Columns().Age.GT(40)).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Equal(t, []uint64{5}, lo.Map(users, func(u User, _ int) uint64 { return u.ID.V }))
+	_, total, err := m.Query().List(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(4), total)
+}
+
+func TestDequeueOne(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db, WithDefaultSort(NewSortOption(NewColumnName("age"), SortOrderAscending)))
+	cols := m.Columns()
+
+	_, err := m.Query().DequeueOne(ctx)
+	assert.NotNil(t, err)
+
+	var ids []uint64
+	assert.Nil(t, NewTransactionFunc(db)(ctx, func(ctx context.Context) error {
+		for {
+			user, err := m.Query(cols.Age.GT(29)).DequeueOne(ctx, cols.Weight.LT(uint(100)))
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			ids = append(ids, user.ID.V)
+			if err := m.Query(cols.ID.EQ(user.ID.V)).Delete(ctx); err != nil {
+				return err
+			}
+		}
+	}))
+	assert.Equal(t, []uint64{3, 2}, ids)
+}
+
+func TestListRaw(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	users, relations := NewModel[User](db), NewModel[Relation](db)
+	cols := users.Columns()
+
+	rows, total, err := users.Query(cols.Age.GT(40)).ListRaw(ctx, ListOptions{
+		Columns:     []ColumnNameGetter{cols.ID, cols.Name, cols.Status},
+		SortOptions: []SortOption{cols.ID.Sort(SortOrderAscending)},
+		Limit:       1,
+	})
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(2), total)
+	assert.Equal(t, []map[string]any{{"id": int64(1), "user_name": "William K Turner", "status": `{"Occupation":"Health Educator"}`}}, rows)
+
+	joined := Join(ctx, users, relations, NewJoinOptions(
+		[]ColumnNameGetter{cols.ID, relations.Columns().Name},
+		cols.Name.EQ(relations.Columns().UserName),
+	))
+	rows, total, err = joined.Query().ListRaw(ctx, ListOptions{SortOptions: []SortOption{joined.Columns().Left.ID.Sort(SortOrderAscending)}})
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(2), total)
+	assert.Equal(t, []map[string]any{
+		{"users.id": int64(1), "relations.name": "relation2"},
+		{"users.id": int64(4), "relations.name": "relation1"},
+	}, rows)
+}
+
+func TestInvalidQueryOp(t *testing.T) {
+	name := ColumnName{Name: "age"}
+	assert.PanicsWithValue(t, `invalid query operator "" on the column age`, func() {
+		NewOpQueryOption(name, "", 1)
+	})
+	assert.PanicsWithValue(t, `invalid query operator "LIKE" on the column age`, func() {
+		NewOpJoinOption(name, "LIKE", ColumnName{Name: "id"})
+	})
+	assert.PanicsWithValue(t, "invalid query option on the column age: operator > can not be applied to values of type bool", func() {
+		NewOpQueryOption(name, OpGt, true)
+	})
+	assert.PanicsWithValue(t, "invalid query option on the column age: operator <= can not be applied to NULL", func() {
+		NewOpQueryOption[*int](name, OpLte, nil)
+	})
+	assert.NotPanics(t, func() {
+		NewOpQueryOption(name, OpGte, time.Now())
+		NewOpQueryOption(name, OpLt, []byte("a"))
+		NewOpQueryOption[any](name, OpEq, nil)
+		NewOpQueryOption(name, OpNe, []string{"a"})
+	})
+}
+
+func TestSyncModel(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewSyncModel(NewModel[User](db))
+	cols := m.Columns()
+
+	user, err := m.GetSync(cols.ID.EQ(uint64(2)))
+	assert.Nil(t, err, err)
+	assert.Equal(t, *u2, user)
+
+	updated, err := m.UpdateSync([]FilterOption{cols.ID.EQ(uint64(2))}, cols.Age.Update(50))
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(1), updated)
+	assert.Nil(t, m.DeleteSync(cols.ID.In([]uint64{1, 3})))
+
+	users, total, err := m.ListSync(ListOptions{}, cols.Age.GT(40))
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(1), total)
+	assert.Equal(t, 50, users[0].Age.V)
+}
+
+func TestPager(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+
+	for _, c := range []struct {
+		pageSize uint64
+		expected [][]uint64
+	}{
+		{pageSize: 2, expected: [][]uint64{{1, 3}, {4}}},
+		{pageSize: 3, expected: [][]uint64{{1, 3, 4}}},
+		{pageSize: 5, expected: [][]uint64{{1, 3, 4}}},
+	} {
+		p := m.Query(cols.ID.NE(uint64(2))).Pager(c.pageSize, cols.ID)
+		var pages [][]uint64
+		for p.Next(ctx) {
+			pages = append(pages, lo.Map(p.Page(), func(u User, _ int) uint64 { return u.ID.V }))
+		}
+		assert.Nil(t, p.Err())
+		assert.Equal(t, c.expected, pages)
+	}
+
+	p := m.Query().Pager(3, cols.Address)
+	var names []string
+	for p.Next(ctx) {
+		names = append(names, lo.Map(p.Page(), func(u User, _ int) string { return u.Name.V })...)
+	}
+	assert.Nil(t, p.Err())
+	assert.Equal(t, []string{"William K Turner", "Jillian B Bennett", "Vera Crawford", "Sebastian Turner"}, names)
+
+	p = m.Query().Pager(0, cols.ID)
+	assert.False(t, p.Next(ctx))
+	assert.NotNil(t, p.Err())
+}
+
+func TestListWithAggregate(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+	aggs := []Aggregate{
+		{Func: AggregateSum, Column: cols.Weight, As: ColumnName{Name: "total_weight"}},
+		{Func: AggregateCount, As: ColumnName{Name: "count"}},
+		{Func: AggregateMax, Column: cols.Name, As: ColumnName{Name: "max_name"}},
+	}
+
+	users, results, total, err := m.Query(cols.ID.NE(uint64(2))).ListWithAggregate(ctx,
+		ListOptions{Limit: 1, SortOptions: []SortOption{cols.ID.Sort(SortOrderAscending)}}, aggs...)
+	assert.Nil(t, err, err)
+	assert.Equal(t, []User{*u1}, users)
+	assert.Equal(t, uint64(3), total)
+	assert.Equal(t, map[string]any{"total_weight": int64(252), "count": int64(3), "max_name": "William K Turner"}, results)
+
+	users, results, total, err = m.Query(cols.ID.GT(uint64(10))).ListWithAggregate(ctx, ListOptions{}, aggs...)
+	assert.Nil(t, err, err)
+	assert.Empty(t, users)
+	assert.Equal(t, uint64(0), total)
+	assert.Equal(t, map[string]any{"total_weight": nil, "count": int64(0), "max_name": nil}, results)
+
+	_, _, _, err = m.Query().ListWithAggregate(ctx, ListOptions{}, Aggregate{Func: AggregateSum, Column: cols.Weight})
+	assert.NotNil(t, err)
+}
+
+func TestCoalesce(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	users, relations := NewModel[User](db), NewModel[Relation](db)
+	uc, rc := users.Columns(), relations.Columns()
+	joined := LeftJoin(ctx, users, relations, NewJoinOptions(
+		[]ColumnNameGetter{uc.ID, Coalesce(rc.Age, -1), Coalesce(rc.Name, "it's none")},
+		uc.Name.EQ(rc.UserName),
+	))
+	jc := joined.Columns()
+	results, err := joined.Query().Find(ctx, ListOptions{SortOptions: []SortOption{jc.Left.ID.Sort(SortOrderAscending)}})
+	assert.Nil(t, err, err)
+	assert.Equal(t, []int{30, -1, -1, 20}, lo.Map(results, func(r JoinedEntity[User, Relation], _ int) int { return r.Right.Age.V }))
+	assert.Equal(t, []string{"relation2", "it's none", "it's none", "relation1"},
+		lo.Map(results, func(r JoinedEntity[User, Relation], _ int) string { return r.Right.Name.V }))
+
+	// the defaults are bound as parameters, also when the select list is extended by the window total
+	joined = LeftJoin(ctx, users, relations, NewJoinOptions(
+		[]ColumnNameGetter{uc.ID, Coalesce(rc.Name, `\' OR 1=1 --`)},
+		uc.Name.EQ(rc.UserName),
+	))
+	results, total, err := joined.Query().List(ctx, ListOptions{WindowTotal: true, SortOptions: []SortOption{jc.Left.ID.Sort(SortOrderAscending)}})
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(4), total)
+	assert.Equal(t, []string{"relation2", `\' OR 1=1 --`, `\' OR 1=1 --`, "relation1"},
+		lo.Map(results, func(r JoinedEntity[User, Relation], _ int) string { return r.Right.Name.V }))
+
+	assert.Nil(t, db.Exec("UPDATE users SET address = NULL WHERE id = 1").Error)
+	list, err := users.Query(uc.ID.EQ(uint64(1))).Find(ctx, ListOptions{Columns: []ColumnNameGetter{uc.ID, Coalesce(uc.Address, "unknown")}})
+	assert.Nil(t, err, err)
+	assert.Equal(t, "unknown", *list[0].Address.V)
+	assert.Panics(t, func() { Coalesce(uc.Age, []int{1}) })
+}
+
+type Ticket struct {
+	ID     Column[uint64] `gorm:"column:id;primaryKey"`
+	Code   Column[string] `gorm:"uniqueIndex"`
+	Status Column[string] `gorm:"index:idx_ticket_status"`
+}
+
+func TestEnsureIndexes(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	assert.Nil(t, db.Exec("CREATE TABLE tickets (id integer PRIMARY KEY, code text, status text)").Error)
+	defer db.Migrator().DropTable(Ticket{})
+	m := NewModel[Ticket](db)
+
+	assert.EqualError(t, m.EnsureIndexes(ctx, false), "missing indexes of the table tickets: idx_ticket_status, idx_tickets_code")
+	assert.Nil(t, m.EnsureIndexes(ctx, true))
+	assert.Nil(t, m.EnsureIndexes(ctx, false))
+	assert.True(t, db.Migrator().HasIndex(Ticket{}, "idx_tickets_code"))
+}
+
+func TestTruncate(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+
+	assert.Nil(t, m.Query(cols.ID.EQ(1)).Delete(ctx))
+	assert.Nil(t, m.Truncate(ctx))
+	var count int64
+	assert.Nil(t, db.Unscoped().Model(&User{}).Count(&count).Error)
+	assert.EqualValues(t, 0, count)
+
+	relations := NewModel[Relation](db)
+	joined := Join(ctx, m, relations, NewJoinOptions(m.ColumnNames(), cols.Name.EQ(relations.Columns().UserName)))
+	assert.EqualError(t, joined.Truncate(ctx), "Truncate is not supported by joined models")
+}
+
+func TestColumnNamesOrder(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+
+	names := lo.Map(m.ColumnNames(), func(c ColumnNameGetter, _ int) string { return c.GetColumnName().Name })
+	assert.Equal(t, []string{"id", "user_name", "age", "address", "status", "embedded_weight", "extra_data",
+		"extra_email", "created_at", "deleted_at"}, names)
+
+	relations := NewModel[Relation](db)
+	joined := Join(ctx, m, relations, NewJoinOptions(m.ColumnNames(), m.Columns().Name.EQ(relations.Columns().UserName)))
+	names = lo.Map(joined.ColumnNames(), func(c ColumnNameGetter, _ int) string { return c.GetColumnName().String() })
+	assert.Equal(t, []string{"users.id", "users.user_name", "users.age", "users.address", "users.status",
+		"users.embedded_weight", "users.extra_data", "users.extra_email", "users.created_at", "users.deleted_at",
+		"relations.id", "relations.name", "relations.user_name", "relations.age"}, names)
+}
+
+func TestQuantifiedQuery(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+
+	for _, c := range []struct {
+		query  FilterOption
+		expect []User
+	}{
+		{query: cols.Age.EqAny([]int{30, 29}), expect: []User{*u3, *u4}},
+		{query: cols.Age.GtAll([]int{30, 45}), expect: []User{*u1, *u2}},
+		{query: cols.Age.LtAny([]int{30, 45}), expect: []User{*u3, *u4}},
+		{query: cols.Age.All(OpNe, []int{46, 49}), expect: []User{*u3, *u4}},
+		{query: cols.Age.GtAll(nil), expect: []User{*u1, *u2, *u3, *u4}},
+		{query: cols.Age.EqAny(nil), expect: []User{}},
+		{query: cols.Address.EqAny([]string{"Michigan, Billings", "4431 Jefferson Street"}), expect: []User{*u3, *u4}},
+	} {
+		users, err := m.Query(c.query).Find(ctx, ListOptions{SortOptions: []SortOption{cols.ID.Sort(SortOrderAscending)}})
+		assert.Nil(t, err, err)
+		assert.Equal(t, c.expect, users)
+	}
+
+	assert.Panics(t, func() { cols.Age.Any(OpJSONContains, []int{1}) })
+	array, err := pgArray([]any{1, `a"b\c`, nil, true, NewColumn("d")})
+	assert.Nil(t, err, err)
+	assert.Equal(t, `{1,"a\"b\\c",NULL,true,"d"}`, array)
+
+	// the values of pointer columns are bound as an array of their elements on Postgres.
+	pg, err := gorm.Open(postgresDialector{sqlite.Open(dbName)}, &gorm.Config{DryRun: true})
+	assert.Nil(t, err, err)
+	var vars []any
+	assert.Nil(t, pg.Callback().Query().After("gorm:query").Register("record", func(db *gorm.DB) {
+		vars = db.Statement.Vars
+	}))
+	m = NewModel[User](pg)
+	_, err = m.Query(m.Columns().Address.EqAny([]string{"a", "b"})).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Equal(t, []any{`{"a","b"}`}, vars)
+}
+
+func TestErrorMapper(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	errNameTaken := errors.New("name taken")
+	var mapped []error
+	m := NewModel[User](db, WithErrorMapper(func(err error) error {
+		mapped = append(mapped, err)
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return errNameTaken
+		}
+		return err
+	}))
+	cols := m.Columns()
+
+	assert.ErrorIs(t, m.Create(ctx, &User{ID: NewColumn[uint64](1)}), errNameTaken)
+	_, err := m.Query(cols.ID.EQ(100)).Get(ctx)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = m.Query(cols.ID.EQ(100)).MaxBy(ctx, cols.Age)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = m.Query().Update(ctx)
+	assert.EqualError(t, err, "empty options")
+	assert.Len(t, mapped, 4)
+
+	missing := NewColumnName("missing")
+	_, err = m.Query().CountBy(ctx, missing)
+	assert.NotNil(t, err)
+	_, err = m.Query().Histogram(ctx, missing, []Bucket{{To: 10}})
+	assert.NotNil(t, err)
+	_, _, err = m.Query().ListRaw(ctx, ListOptions{SortOptions: []SortOption{NewSortOption(missing, SortOrderAscending)}})
+	assert.NotNil(t, err)
+	assert.NotNil(t, m.Query().Export(ctx, ListOptions{}, io.Discard, "xml"))
+	assert.Len(t, mapped, 8)
+
+	_, err = m.Query().Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Len(t, mapped, 8)
+}
+
+func TestValueOf(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+
+	v, err := m.ValueOf(u1, cols.Name)
+	assert.Nil(t, err, err)
+	assert.Equal(t, "William K Turner", v)
+	v, err = m.ValueOf(u1, cols.Extra.Inner.Data)
+	assert.Nil(t, err, err)
+	assert.Equal(t, u1.Extra.Inner.Data.V, v)
+	v, err = m.ValueOf(u1, cols.Address)
+	assert.Nil(t, err, err)
+	assert.Equal(t, u1.Address.V, v)
+	_, err = m.ValueOf(u1, NewColumnName("unknown"))
+	assert.EqualError(t, err, "the column unknown does not belong to the table users")
+}
+
+func TestSetValue(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+
+	var user User
+	assert.Nil(t, m.SetValue(&user, cols.Name, "Tom"))
+	assert.Nil(t, m.SetValue(&user, cols.Age, int64(20)))
+	assert.Nil(t, m.SetValue(&user, cols.Address, "street"))
+	assert.Nil(t, m.SetValue(&user, cols.Weight, 70))
+	assert.Equal(t, "Tom", user.Name.V)
+	assert.Equal(t, 20, user.Age.V)
+	assert.Equal(t, "street", *user.Address.V)
+	assert.Equal(t, uint(70), user.Weight.V)
+	assert.Nil(t, m.SetValue(&user, cols.Address, nil))
+	assert.Nil(t, user.Address.V)
+
+	assert.EqualError(t, m.SetValue(&user, cols.Age, "old"),
+		"failed to set the column age: unable to convert value of type string to the column type int")
+	assert.EqualError(t, m.SetValue(&user, NewColumnName("unknown"), 1), "the column unknown does not belong to the table users")
+}
+
+func TestFlags(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+
+	users, err := m.Query(cols.Weight.HasFlag(3)).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Equal(t, []User{*u1, *u2}, users)
+	users, err = m.Query(cols.Weight.HasFlag(36)).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Equal(t, []User{*u3, *u4}, users)
+
+	_, err = m.Query(cols.ID.EQ(1)).Update(ctx, cols.Weight.SetFlag(16))
+	assert.Nil(t, err, err)
+	_, err = m.Query(cols.ID.EQ(1)).Update(ctx, cols.Weight.ClearFlag(3))
+	assert.Nil(t, err, err)
+	user, err := m.Query(cols.ID.EQ(1)).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint(120), user.Weight.V)
+
+	assert.Panics(t, func() { cols.Name.HasFlag(1) })
+	assert.Panics(t, func() { NewOpQueryOption(cols.Age.GetColumnName(), OpHasFlag, 1) })
+}
+
+type Account struct {
+	ID        Column[uint64] `gorm:"column:id;primaryKey"`
+	Name      Column[string]
+	IsDeleted Column[bool]
+}
+
+func TestSoftDeleteFlag(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	assert.Nil(t, db.AutoMigrate(Account{}))
+	defer db.Migrator().DropTable(Account{})
+	m := NewModel[Account](db, WithSoftDeleteFlag(NewColumnName("is_deleted")))
+	cols := m.Columns()
+	for i := uint64(1); i <= 3; i++ {
+		assert.Nil(t, m.Create(ctx, &Account{ID: NewColumn(i), Name: NewColumn(fmt.Sprint("account", i))}))
+	}
+
+	ids := func() []uint64 {
+		accounts, err := m.Query().Find(ctx, ListOptions{SortOptions: []SortOption{cols.ID.Sort(SortOrderAscending)}})
+		assert.Nil(t, err, err)
+		return lo.Map(accounts, func(a Account, _ int) uint64 { return a.ID.V })
+	}
+	assert.Nil(t, m.Query(cols.ID.EQ(1)).Delete(ctx))
+	assert.Equal(t, []uint64{2, 3}, ids())
+	var deleted int64
+	assert.Nil(t, db.Model(&Account{}).Where("is_deleted = ?", true).Count(&deleted).Error)
+	assert.EqualValues(t, 1, deleted)
+	updated, err := m.Query().Update(ctx, cols.Name.Update("renamed"))
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, 2, updated)
+
+	restored, err := m.Query(cols.ID.In([]uint64{1, 2})).Restore(ctx)
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, 1, restored)
+	assert.Equal(t, []uint64{1, 2, 3}, ids())
+	account, err := m.Query(cols.ID.EQ(1)).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, "account1", account.Name.V)
+
+	users := NewModel[User](db)
+	deletedUsing, err := m.Query(cols.ID.EQ(1)).DeleteUsing(ctx, users, []OpOption{cols.Name.NE(users.Columns().Name)})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, 1, deletedUsing)
+	assert.Equal(t, []uint64{2, 3}, ids())
+	assert.Nil(t, db.Model(&Account{}).Where("is_deleted = ?", true).Count(&deleted).Error)
+	assert.EqualValues(t, 1, deleted)
+	_, err = m.Query(cols.ID.EQ(1)).Restore(ctx)
+	assert.Nil(t, err, err)
+
+	_, err = NewModel[User](db).Query().Restore(ctx)
+	assert.EqualError(t, err, "Restore is only supported by models with a soft delete flag")
+	assert.Panics(t, func() { NewModel[Account](db, WithSoftDeleteFlag(NewColumnName("deleted"))) })
+}
+
+func TestJoinedQueryCancellation(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	users, relations := NewModel[User](db), NewModel[Relation](db)
+	joined := Join(context.Background(), users, relations,
+		NewJoinOptions(users.ColumnNames(), users.Columns().Name.EQ(relations.Columns().UserName)))
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err := joined.Query().List(canceled, ListOptions{})
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = joined.Query().Get(canceled)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	results, total, err := joined.Query().List(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, 2, total)
+	assert.Len(t, results, 2)
+}
+
+func TestJoinedModelContext(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	users, relations := NewModel[User](db), NewModel[Relation](db)
+	opts := NewJoinOptions(users.ColumnNames(), users.Columns().Name.EQ(relations.Columns().UserName))
+
+	var joined Model[JoinedEntity[User, Relation]]
+	assert.Nil(t, InTransaction(ctx, db, func(ctx context.Context) error {
+		joined = Join(ctx, users, relations, opts)
+		return nil
+	}))
+	// the transaction the join was built in is committed, later calls must not use it.
+	_, total, err := joined.Query().List(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, 2, total)
+
+	joined = Join(ctx, users, relations, opts)
+	errRollback := errors.New("rollback")
+	assert.ErrorIs(t, InTransaction(ctx, db, func(ctx context.Context) error {
+		if err := users.Query(users.Columns().ID.EQ(uint64(1))).Delete(ctx); err != nil {
+			return err
+		}
+		// the transaction of the call is used rather than the db the join was built with.
+		_, total, err := joined.Query().List(ctx, ListOptions{})
+		assert.Nil(t, err, err)
+		assert.EqualValues(t, 1, total)
+		return errRollback
+	}), errRollback)
+}
+
+func TestJoinFilterOnUnselectedColumn(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	users, relations := NewModel[User](db), NewModel[Relation](db)
+	joined := Join(ctx, users, relations,
+		NewJoinOptions(users.ColumnNames(), users.Columns().Name.EQ(relations.Columns().UserName)))
+	cols := joined.Columns()
+
+	assert.Equal(t, "relations.age", cols.Right.Age.GetColumnName().Full())
+	results, total, err := joined.Query(cols.Right.Age.GT(25), cols.Right.Name.NE("")).List(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, 1, total)
+	assert.EqualValues(t, []JoinedEntity[User, Relation]{{Left: *u1}}, removeListColumnNames(results))
+}
+
+func TestJoinedGetNotFound(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	users, relations := NewModel[User](db), NewModel[Relation](db)
+	joined := Join(ctx, users, relations,
+		NewJoinOptions(users.ColumnNames(), users.Columns().Name.EQ(relations.Columns().UserName)))
+
+	_, err := joined.Query(joined.Columns().Left.Age.GT(100)).Get(ctx)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = users.Query(users.Columns().Age.GT(100)).Get(ctx)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	entity, err := joined.Query(joined.Columns().Left.Age.GT(40)).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, "William K Turner", entity.Left.Name.V)
+}
+
+func TestJoinWithFilters(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	users, relations := NewModel[User](db), NewModel[Relation](db)
+	opts := NewJoinOptions(users.ColumnNames(), users.Columns().Name.EQ(relations.Columns().UserName)).
+		WithFilters(users.Columns().Status.EQ(Status{Occupation: "Collage student"}), relations.Columns().Age.LT(25))
+	joined := Join(ctx, users, relations, opts)
+
+	results, total, err := joined.Query().List(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, 1, total)
+	assert.EqualValues(t, []JoinedEntity[User, Relation]{{Left: *u4}}, removeListColumnNames(results))
+
+	results, total, err = joined.Query(joined.Columns().Left.Age.GT(40)).List(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, 0, total)
+	assert.Empty(t, results)
+}
+
+type Envelope struct {
+	ID      Column[uint64] `gorm:"column:id;primaryKey"`
+	Payload Column[Status] `gorm:"serializer:json,json"`
+}
+
+func TestSerializerChain(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	// chains are registered to gorm by NewModel, so the model is created before migrating.
+	m := NewModel[Envelope](db)
+	assert.Nil(t, db.AutoMigrate(Envelope{}))
+	defer db.Migrator().DropTable(Envelope{})
+	cols := m.Columns()
+
+	assert.Nil(t, m.Create(ctx, &Envelope{ID: NewColumn(uint64(1)), Payload: NewColumn(Status{Occupation: "Teacher"})}))
+	var raw string
+	assert.Nil(t, db.Table("envelopes").Select("payload").Where("id = ?", 1).Scan(&raw).Error)
+	assert.Equal(t, `"{\"Occupation\":\"Teacher\"}"`, raw)
+
+	e, err := m.Query(cols.Payload.EQ(Status{Occupation: "Teacher"})).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, "Teacher", e.Payload.V.Occupation)
+
+	_, err = m.Query(cols.ID.EQ(1)).Update(ctx, cols.Payload.Update(Status{Occupation: "Student"}))
+	assert.Nil(t, err, err)
+	e, err = m.Query(cols.ID.EQ(1)).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, "Student", e.Payload.V.Occupation)
+}
+
+type Archive struct {
+	ID      Column[uint64] `gorm:"column:id;primaryKey"`
+	Payload Column[Status] `gorm:"serializer:json,gzip"`
+	Blob    Column[[]byte] `gorm:"serializer:gzip"`
+}
+
+func TestGzipSerializer(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[Archive](db)
+	assert.Nil(t, db.AutoMigrate(Archive{}))
+	defer db.Migrator().DropTable(Archive{})
+	cols := m.Columns()
+
+	blob := []byte(strings.Repeat("payload", 100))
+	assert.Nil(t, m.Create(ctx, &Archive{
+		ID:      NewColumn(uint64(1)),
+		Payload: NewColumn(Status{Occupation: "Teacher"}),
+		Blob:    NewColumn(blob),
+	}))
+	var raw []byte
+	assert.Nil(t, db.Table("archives").Select("blob").Where("id = ?", 1).Row().Scan(&raw))
+	assert.Less(t, len(raw), len(blob))
+	assert.Equal(t, []byte{0x1f, 0x8b}, raw[:2])
+
+	a, err := m.Query(cols.ID.EQ(1)).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, "Teacher", a.Payload.V.Occupation)
+	assert.Equal(t, blob, a.Blob.V)
+
+	_, err = m.Query(cols.ID.EQ(1)).Update(ctx, cols.Blob.Update([]byte("updated")), cols.Payload.Update(Status{Occupation: "Student"}))
+	assert.Nil(t, err, err)
+	a, err = m.Query(cols.ID.EQ(1)).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, "Student", a.Payload.V.Occupation)
+	assert.Equal(t, []byte("updated"), a.Blob.V)
+}
+
+func TestRawEQ(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+
+	users, err := m.Query(cols.Status.RawEQ(`{"Occupation":"Teacher"}`)).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{*u3}, users)
+
+	// the raw form is not serialized again, so it does not match the JSON string.
+	users, err = m.Query(cols.Status.RawEQ(`"Teacher"`)).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Empty(t, users)
+}
+
+func TestWithScopeIf(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+	relations := NewModel[Relation](db)
+	rcols := relations.Columns()
+
+	assert.Equal(t, ctx, WithScopeIf(ctx, false, cols.Age.GT(40)))
+	scoped := WithScopeIf(ctx, true, cols.Age.GT(40))
+	scoped = WithScopeIf(scoped, true, Or(cols.Name.EQ("William K Turner"), cols.Name.EQ("Vera Crawford")))
+	users, err := m.Query().Find(scoped, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{*u1}, users)
+	_, total, err := m.Query().List(scoped, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, 1, total)
+
+	// the scopes of users are skipped by relations.
+	rs, err := relations.Query().Find(scoped, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Len(t, rs, 3)
+
+	// and applied to joined models containing users.
+	joined := Join(ctx, m, relations, NewJoinOptions(
+		[]ColumnNameGetter{cols.Name, rcols.Age}, cols.Name.EQ(rcols.UserName)))
+	results, err := joined.Query().Find(scoped, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Len(t, results, 1)
+
+	// writes are not scoped.
+	n, err := m.Query(cols.Age.LT(40)).Update(scoped, cols.Address.Update("scoped"))
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, 2, n)
+}
+
+func TestHistogram(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+
+	buckets := []Bucket{{To: 30}, {From: 30, To: 40}, {From: 40}, {From: 100, To: 200}}
+	counts, err := m.Query().Histogram(ctx, cols.Age, buckets)
+	assert.Nil(t, err, err)
+	assert.Equal(t, []BucketCount{
+		{Bucket: buckets[0], Count: 1},
+		{Bucket: buckets[1], Count: 1},
+		{Bucket: buckets[2], Count: 2},
+		{Bucket: buckets[3], Count: 0},
+	}, counts)
+
+	// entities are counted in the first bucket containing them.
+	counts, err = m.Query(cols.Age.GT(29)).Histogram(ctx, cols.Age, []Bucket{{From: 40}, {}})
+	assert.Nil(t, err, err)
+	assert.Equal(t, []uint64{2, 1}, []uint64{counts[0].Count, counts[1].Count})
+}
+
+func TestCreateReturningID(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[Relation](db)
+
+	r := &Relation{Name: NewColumn("r4"), UserName: NewColumn("Sebastian Turner"), Age: NewColumn(50)}
+	id, err := m.CreateReturningID(ctx, r)
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, 4, id)
+	assert.EqualValues(t, id, r.ID.V)
+
+	err = InTransaction(ctx, db, func(ctx context.Context) error {
+		id, err = m.CreateReturningID(ctx, &Relation{Name: NewColumn("r5")})
+		return err
+	})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, 5, id)
+
+	errDuplicated := errors.New("duplicated")
+	var mapped []error
+	m = NewModel[Relation](db, WithErrorMapper(func(err error) error {
+		mapped = append(mapped, err)
+		return errDuplicated
+	}))
+	_, err = m.CreateReturningID(ctx, &Relation{ID: NewColumn[uint64](1)})
+	assert.ErrorIs(t, err, errDuplicated)
+	assert.Len(t, mapped, 1)
+
+	_, err = NewModel[Relation](db, WithStatementTimeout(time.Nanosecond)).CreateReturningID(ctx, &Relation{Name: NewColumn("r6")})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestDeleteInBatches(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db, WithSafeDestructive())
+	cols := m.Columns()
+
+	_, err := m.Query().DeleteInBatches(ctx, 2)
+	assert.ErrorIs(t, err, ErrMissingFilters)
+	_, err = m.Query(cols.Age.LT(50)).DeleteInBatches(ctx, 0)
+	assert.EqualError(t, err, "batch size must be positive")
+
+	deleted, err := m.Query(cols.Age.GT(29)).DeleteInBatches(ctx, 2)
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, 3, deleted)
+	users, err := m.Query().Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{*u4}, users)
+
+	relations := NewModel[Relation](db)
+	deleted, err = relations.Query().DeleteInBatches(ctx, 1)
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, 3, deleted)
+}
+
+func TestCreateOrIgnore(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[Relation](db)
+	cols := m.Columns()
+
+	created, err := m.CreateOrIgnore(ctx, &Relation{ID: NewColumn(uint64(1)), Name: NewColumn("conflicted")})
+	assert.Nil(t, err, err)
+	assert.False(t, created)
+	r, err := m.Query(cols.ID.EQ(1)).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, "relation1", r.Name.V)
+
+	created, err = m.CreateOrIgnore(ctx, &Relation{ID: NewColumn(uint64(4)), Name: NewColumn("r4")})
+	assert.Nil(t, err, err)
+	assert.True(t, created)
+}
+
+func TestColumnAlias(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	users, relations := NewModel[User](db), NewModel[Relation](db)
+	cols, rcols := users.Columns(), relations.Columns()
+
+	joined := Join(ctx, users, relations, NewJoinOptions(
+		[]ColumnNameGetter{cols.ID.As("user_id"), cols.Name, rcols.Name.As("relation_name")},
+		cols.Name.EQ(rcols.UserName),
+	))
+	sorts := []SortOption{joined.Columns().Left.ID.Sort(SortOrderAscending)}
+	rows, _, err := joined.Query().ListRaw(ctx, ListOptions{SortOptions: sorts})
+	assert.Nil(t, err, err)
+	assert.Equal(t, []map[string]any{
+		{"user_id": int64(1), "users.user_name": "William K Turner", "relation_name": "relation2"},
+		{"user_id": int64(4), "users.user_name": "Vera Crawford", "relation_name": "relation1"},
+	}, rows)
+
+	// joined entities are scanned from the aliases.
+	results, err := joined.Query(joined.Columns().Left.ID.EQ(4)).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, [3]any{uint64(4), "Vera Crawford", "relation1"}, [3]any{results[0].Left.ID.V, results[0].Left.Name.V, results[0].Right.Name.V})
+	results, err = joined.Query().Find(ctx, ListOptions{
+		Columns:     []ColumnNameGetter{joined.Columns().Right.Name.As("r")},
+		SortOptions: sorts,
+	})
+	assert.Nil(t, err, err)
+	assert.Equal(t, []string{"relation2", "relation1"}, lo.Map(results, func(r JoinedEntity[User, Relation], _ int) string { return r.Right.Name.V }))
+
+	type flat struct {
+		UserID       uint64
+		RelationName string
+	}
+	sqlRows, err := joined.Query().Rows(ctx, ListOptions{SortOptions: sorts})
+	assert.Nil(t, err, err)
+	defer sqlRows.Close()
+	var flats []flat
+	for sqlRows.Next() {
+		var f flat
+		assert.Nil(t, db.ScanRows(sqlRows, &f))
+		flats = append(flats, f)
+	}
+	assert.Equal(t, []flat{{1, "relation2"}, {4, "relation1"}}, flats)
+
+	rows, _, err = users.Query(cols.ID.EQ(1)).ListRaw(ctx, ListOptions{Columns: []ColumnNameGetter{cols.Name.As("name")}})
+	assert.Nil(t, err, err)
+	assert.Equal(t, []map[string]any{{"name": "William K Turner"}}, rows)
+}
+
+func TestRowValues(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+
+	sorts := []SortOption{cols.Age.Sort(SortOrderAscending), cols.ID.Sort(SortOrderAscending)}
+	users, err := m.Query(RowValues(cols.Age, cols.ID).GT(30, 3)).Find(ctx, ListOptions{SortOptions: sorts})
+	assert.Nil(t, err, err)
+	assert.Equal(t, []uint64{1, 2}, lo.Map(users, func(u User, _ int) uint64 { return u.ID.V }))
+
+	users, err = m.Query(RowValues(cols.Age, cols.ID).LTE(30, 3)).Find(ctx, ListOptions{SortOptions: sorts})
+	assert.Nil(t, err, err)
+	assert.Equal(t, []uint64{4, 3}, lo.Map(users, func(u User, _ int) uint64 { return u.ID.V }))
+
+	// the expanded form is described.
+	assert.Equal(t, []FilterDescriptor{{Type: FilterOptionTypeGroup, Op: "OR", Filters: []FilterDescriptor{
+		{Type: FilterOptionTypeGroup, Op: "AND", Filters: []FilterDescriptor{
+			{Type: FilterOptionTypeOpQuery, Column: "users.age", Op: ">", Value: 30},
+		}},
+		{Type: FilterOptionTypeGroup, Op: "AND", Filters: []FilterDescriptor{
+			{Type: FilterOptionTypeOpQuery, Column: "users.age", Op: "=", Value: 30},
+			{Type: FilterOptionTypeOpQuery, Column: "users.id", Op: ">", Value: 3},
+		}},
+	}}}, DescribeFilters([]FilterOption{RowValues(cols.Age, cols.ID).GT(30, 3)}))
+	assert.Panics(t, func() { RowValues(cols.Age, cols.ID).GT(30) })
+}
+
+func TestSortByValues(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	cols := m.Columns()
+
+	ids := []uint64{3, 1, 4}
+	users, err := m.Query(cols.ID.In(ids)).Find(ctx, ListOptions{SortOptions: []SortOption{cols.ID.SortByValues(ids)}})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{*u3, *u1, *u4}, users)
+
+	users, err = m.Query().Find(ctx, ListOptions{SortOptions: []SortOption{
+		cols.Status.SortByValues([]Status{{Occupation: "Teacher"}}),
+		cols.Age.Sort(SortOrderDescending),
+	}})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{*u3, *u2, *u1, *u4}, users)
+}
+
+func TestGetByIDsOrdered(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+	id := func(u *User) *Column[uint64] { return &u.ID }
+
+	users, err := GetByIDs(ctx, m, id, []uint64{3, 1, 10})
+	assert.Nil(t, err, err)
+	assert.Equal(t, map[uint64]User{1: *u1, 3: *u3}, users)
+
+	ordered, err := GetByIDsOrdered(ctx, m, id, []uint64{3, 10, 1, 3})
+	assert.Nil(t, err, err)
+	assert.Equal(t, []User{*u3, {}, *u1, *u3}, ordered)
+}
+
+func TestStream(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db)
+
+	var users []User
+	entities, errs := m.Query().Stream(ctx, ListOptions{})
+	for entity := range entities {
+		users = append(users, entity)
+	}
+	assert.Nil(t, <-errs)
+	assert.EqualValues(t, []User{*u1, *u2, *u3, *u4}, users)
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	entities, errs = m.Query().Stream(cancelCtx, ListOptions{})
+	<-entities
+	cancel()
+	for range entities {
+	}
+	assert.ErrorIs(t, <-errs, context.Canceled)
+}
+
+func TestQualifiedColumns(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	m := NewModel[User](db, WithQualifiedColumns(), WithDBInitialFunc(func(db *gorm.DB) *gorm.DB {
+		return db.Select("users.*").Joins("JOIN relations ON relations.user_name = users.user_name")
+	}))
+	cols := m.Columns()
+
+	users, err := m.Query(cols.Age.GT(20), cols.ID.In([]uint64{1, 2, 3, 4})).Find(ctx, ListOptions{
+		SortOptions: []SortOption{cols.ID.Sort(SortOrderDescending)},
+	})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{*u4, *u1}, users)
+
+	updated, err := NewModel[User](db, WithQualifiedColumns()).Query(cols.ID.EQ(uint64(4))).Update(ctx, cols.Age.Update(30))
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(1), updated)
+}
+
+func TestWindowTotal(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	users, relations := NewModel[User](db), NewModel[Relation](db)
+
+	for _, c := range []struct {
+		opts        ListOptions
+		expectTotal uint64
+		expect      []User
+	}{
+		{
+			opts:        ListOptions{Limit: 2, SortOptions: []SortOption{users.Columns().ID.Sort(SortOrderAscending)}, WindowTotal: true},
+			expectTotal: 4,
+			expect:      []User{*u1, *u2},
+		},
+		{
+			opts:        ListOptions{Offset: 10, WindowTotal: true},
+			expectTotal: 4,
+			expect:      []User{},
+		},
+	} {
+		results, total, err := users.Query().List(ctx, c.opts)
+		assert.Nil(t, err, err)
+		assert.Equal(t, c.expectTotal, total)
+		assert.EqualValues(t, c.expect, results)
+	}
+
+	// the entities are scanned into the slice of the caller.
+	dest := make([]User, 0, 8)
+	total, err := users.Query().ListInto(ctx, ListOptions{SortOptions: []SortOption{users.Columns().ID.Sort(SortOrderAscending)}, WindowTotal: true}, &dest)
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(4), total)
+	assert.Equal(t, []User{*u1, *u2, *u3, *u4}, dest)
+	assert.Equal(t, 8, cap(dest))
+
+	joined := Join(ctx, users, relations, NewJoinOptions(
+		append(users.ColumnNames(), relations.ColumnNames()...),
+		users.Columns().Name.EQ(relations.Columns().UserName),
+	))
+	results, total, err := joined.Query().List(ctx, ListOptions{Limit: 1, WindowTotal: true})
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(2), total)
+	assert.Len(t, results, 1)
+}
+
+func TestJoinOverlappingColumns(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	users, relations := NewModel[User](db), NewModel[Relation](db)
+
+	joined := LeftJoin(ctx, users, relations, NewJoinOptions(
+		[]ColumnNameGetter{users.Columns().ID, users.Columns().Age, relations.Columns().ID, relations.Columns().Age},
+		users.Columns().Name.EQ(relations.Columns().UserName),
+	))
+	cols := joined.Columns()
+	assert.Equal(t, "users.id", cols.Left.ID.String())
+	assert.Equal(t, "relations.id", cols.Right.ID.String())
+
+	results, err := joined.Query().Find(ctx, ListOptions{SortOptions: []SortOption{cols.Left.ID.Sort(SortOrderAscending)}})
+	assert.Nil(t, err, err)
+	assert.Equal(t, [][4]any{
+		{uint64(1), 46, uint64(2), 30},
+		{uint64(2), 49, uint64(0), 0},
+		{uint64(3), 30, uint64(0), 0},
+		{uint64(4), 29, uint64(1), 20},
+	}, lo.Map(results, func(r JoinedEntity[User, Relation], _ int) [4]any {
+		return [4]any{r.Left.ID.V, r.Left.Age.V, r.Right.ID.V, r.Right.Age.V}
+	}))
+}
+
+func TestJoinedRowsScan(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	users, relations := NewModel[User](db), NewModel[Relation](db)
+
+	joined := LeftJoin(ctx, users, relations, NewJoinOptions(
+		[]ColumnNameGetter{users.Columns().ID, users.Columns().Name, relations.Columns().ID, relations.Columns().Name, relations.Columns().Age},
+		users.Columns().Name.EQ(relations.Columns().UserName),
+	))
+	opts := ListOptions{SortOptions: []SortOption{joined.Columns().Left.ID.Sort(SortOrderAscending)}}
+	// the columns of relations are NULL in the rows of users without relations.
+	expected := [][5]any{
+		{uint64(1), "William K Turner", uint64(2), "relation2", 30},
+		{uint64(2), "Jillian B Bennett", uint64(0), "", 0},
+		{uint64(3), "Sebastian Turner", uint64(0), "", 0},
+		{uint64(4), "Vera Crawford", uint64(1), "relation1", 20},
+	}
+	flatten := func(results []JoinedEntity[User, Relation]) [][5]any {
+		return lo.Map(results, func(r JoinedEntity[User, Relation], _ int) [5]any {
+			return [5]any{r.Left.ID.V, r.Left.Name.V, r.Right.ID.V, r.Right.Name.V, r.Right.Age.V}
+		})
+	}
+
+	results, err := joined.Query().Find(ctx, opts)
+	assert.Nil(t, err, err)
+	assert.Equal(t, expected, flatten(results))
+
+	windowOpts := opts
+	windowOpts.WindowTotal = true
+	results, total, err := joined.Query().List(ctx, windowOpts)
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(4), total)
+	assert.Equal(t, expected, flatten(results))
+
+	entities, errs := joined.Query().Stream(ctx, opts)
+	results = nil
+	for entity := range entities {
+		results = append(results, entity)
+	}
+	assert.Nil(t, <-errs)
+	assert.Equal(t, expected, flatten(results))
+}
+
+func TestJoinRawConditions(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	users, relations := NewModel[User](db), NewModel[Relation](db)
+
+	joined := LeftJoin(ctx, users, relations, NewJoinOptions(
+		[]ColumnNameGetter{users.Columns().ID, relations.Columns().ID},
+		users.Columns().Name.EQ(relations.Columns().UserName),
+	).WithRawConditions(gorm.Expr("relations.age > ?", 25), gorm.Expr("relations.name IS NULL OR relations.name != ''")))
+	results, err := joined.Query().Find(ctx, ListOptions{SortOptions: []SortOption{joined.Columns().Left.ID.Sort(SortOrderAscending)}})
+	assert.Nil(t, err, err)
+	assert.Equal(t, [][2]uint64{{1, 2}, {2, 0}, {3, 0}, {4, 0}}, lo.Map(results, func(r JoinedEntity[User, Relation], _ int) [2]uint64 {
+		return [2]uint64{r.Left.ID.V, r.Right.ID.V}
+	}))
+}
+
+type Employee struct {
+	ID        Column[uint64] `gorm:"column:id;primaryKey"`
+	Name      Column[string]
+	ManagerID PtrColumn[uint64]
+}
+
+func TestSelfJoin(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	assert.Nil(t, db.AutoMigrate(Employee{}))
+	defer db.Migrator().DropTable(Employee{})
+	employees := NewModel[Employee](db, WithTableAlias("e"))
+	managers := NewModel[Employee](db, WithTableAlias("m"))
+	for _, e := range []*Employee{
+		{ID: NewColumn[uint64](1), Name: NewColumn("boss")},
+		{ID: NewColumn[uint64](2), Name: NewColumn("alice"), ManagerID: NewPtrColumn[uint64](1)},
+		{ID: NewColumn[uint64](3), Name: NewColumn("bob"), ManagerID: NewPtrColumn[uint64](2)},
+	} {
+		assert.Nil(t, employees.Create(ctx, e))
+	}
+	assert.Equal(t, "e.id", employees.Columns().ID.GetColumnName().Full())
+	assert.Equal(t, "m.id", managers.Columns().ID.GetColumnName().Full())
+
+	joined := Join(ctx, employees, managers, NewJoinOptions(
+		[]ColumnNameGetter{employees.Columns().Name, managers.Columns().Name},
+		employees.Columns().ManagerID.EQ(managers.Columns().ID),
+	))
+	results, err := joined.Query(joined.Columns().Right.Name.NE("boss")).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Equal(t, [][2]string{{"bob", "alice"}}, lo.Map(results, func(r JoinedEntity[Employee, Employee], _ int) [2]string {
+		return [2]string{r.Left.Name.V, r.Right.Name.V}
+	}))
+
+	bosses, err := managers.Query(managers.Columns().ManagerID.EqNullSafe(nil)).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Len(t, bosses, 1)
+}
+
+func TestUpdateFrom(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+
+	users, relations := NewModel[User](db), NewModel[Relation](db)
+	updated, err := users.Query(users.Columns().Age.GT(40)).UpdateFrom(ctx, relations,
+		[]OpOption{users.Columns().Name.EQ(relations.Columns().UserName)},
+		users.Columns().Weight.Update(Ref(relations.Columns().Age)),
+		users.Columns().Extra.Email.Update("updated@example.com"),
+	)
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(1), updated)
+
+	results, err := users.Query().Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{func() User {
+		u := *u1
+		u.Weight.V = 30
+		u.Extra.Email.V = "updated@example.com"
+		return u
+	}(), *u2, *u3, *u4}, results)
+
+	safe := NewModel[User](db, WithSafeDestructive())
+	_, err = safe.Query().UpdateFrom(ctx, relations, []OpOption{safe.Columns().Name.EQ(relations.Columns().UserName)},
+		safe.Columns().Age.Update(Ref(relations.Columns().Age)))
+	assert.ErrorIs(t, err, ErrMissingFilters)
+
+	// rows soft deleted by gorm.DeletedAt are skipped.
+	assert.Nil(t, users.Query(users.Columns().ID.EQ(uint64(1))).Delete(ctx))
+	aliased := NewModel[User](db, WithTableAlias("u"))
+	updated, err = aliased.Query(aliased.Columns().Age.GT(0)).UpdateFrom(ctx, relations,
+		[]OpOption{aliased.Columns().Name.EQ(relations.Columns().UserName)},
+		aliased.Columns().Age.Update(Ref(relations.Columns().Age)),
+	)
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(1), updated)
+	user, err := users.Query(users.Columns().ID.EQ(uint64(4))).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, 20, user.Age.V)
+}
+
+func TestDeleteUsing(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+
+	users, relations := NewModel[User](db), NewModel[Relation](db)
+	deleted, err := users.Query(users.Columns().Age.LT(40)).DeleteUsing(ctx, relations,
+		[]OpOption{users.Columns().Name.EQ(relations.Columns().UserName)},
+		relations.Columns().Age.LT(25),
+	)
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(1), deleted)
+
+	results, err := users.Query().Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{*u1, *u2, *u3}, results)
+
+	safe := NewModel[User](db, WithSafeDestructive())
+	_, err = safe.Query().DeleteUsing(ctx, relations, []OpOption{safe.Columns().Name.EQ(relations.Columns().UserName)})
+	assert.ErrorIs(t, err, ErrMissingFilters)
+	_, err = safe.Query().DeleteUnmatched(ctx, relations, []OpOption{safe.Columns().Name.EQ(relations.Columns().UserName)})
+	assert.ErrorIs(t, err, ErrMissingFilters)
+	_, err = users.Query().DeleteUsing(ctx, relations, nil)
+	assert.NotNil(t, err)
+}
+
+func TestDeleteUnmatched(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+
+	users, relations := NewModel[User](db), NewModel[Relation](db)
+	deleted, err := users.Query(users.Columns().Age.GT(0)).DeleteUnmatched(ctx, relations,
+		[]OpOption{users.Columns().Name.EQ(relations.Columns().UserName)},
+	)
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(2), deleted)
+
+	results, err := users.Query().Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.EqualValues(t, []User{*u1, *u4}, results)
+}
+
+type Parcel struct {
+	ID        Column[uint64] `gorm:"column:id;primaryKey"`
+	*Embedded `gorm:"embeddedPrefix:parcel_"`
+	Extra     *Extra `gorm:"embedded;embeddedPrefix:extra_"`
+}
+
+func TestEmbeddedPtr(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	assert.Nil(t, db.AutoMigrate(Parcel{}))
+	m := NewModel[Parcel](db)
+	cols := m.Columns()
+	assert.Equal(t, "parcel_weight", cols.Weight.GetColumnName().String())
+	assert.Equal(t, "extra_data", cols.Extra.Inner.Data.GetColumnName().String())
+	assert.Equal(t, "extra_email", cols.Extra.Email.GetColumnName().String())
+
+	p := Parcel{ID: NewColumn[uint64](1), Embedded: &Embedded{Weight: NewColumn[uint](10)}, Extra: &Extra{Email: NewColumn("a@b.c")}}
+	assert.Nil(t, m.Create(ctx, &p))
+	parcel, err := m.Query(cols.Weight.EQ(uint(10)), cols.Extra.Email.EQ("a@b.c")).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, p, parcel)
+	assert.Equal(t, "parcel_weight", cols.Weight.GetColumnName().String())
+}
+
+func TestEmbeddedPtrNil(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	assert.Nil(t, db.AutoMigrate(Parcel{}))
+	m := NewModel[Parcel](db, WithJSONKeyStrategy(JSONKeyColumnName))
+	cols := m.Columns()
+
+	// the columns behind nil embedded pointers are NULL and the pointers are left nil
+	p := Parcel{ID: NewColumn[uint64](1)}
+	v, err := m.ValueOf(&p, cols.Weight)
+	assert.Nil(t, err, err)
+	assert.Nil(t, v)
+	data, err := m.Marshal(p)
+	assert.Nil(t, err, err)
+	assert.JSONEq(t, `{"id":1,"parcel_weight":null,"extra_data":null,"extra_email":null}`, string(data))
+	assert.Nil(t, p.Embedded)
+	assert.Nil(t, p.Extra)
+
+	assert.Nil(t, m.Create(ctx, &p))
+	updated := Parcel{ID: NewColumn[uint64](1)}
+	_, err = m.UpdateDiff(ctx, &p, &updated)
+	assert.Nil(t, err, err)
+	assert.Nil(t, p.Embedded)
+	assert.Nil(t, updated.Embedded)
+
+	header, record, err := m.Query().(executor[Parcel]).csvRecord(&p, nil)
+	assert.Nil(t, err, err)
+	assert.Equal(t, []string{"id", "parcel_weight", "extra_data", "extra_email"}, header)
+	assert.Equal(t, []string{"1", "", "", ""}, record)
+	assert.Nil(t, p.Embedded)
+
+	// setting NULL keeps the pointers nil, other values allocate them
+	assert.Nil(t, m.SetValue(&p, cols.Weight, nil))
+	assert.Nil(t, p.Embedded)
+	assert.Nil(t, m.SetValue(&p, cols.Extra.Email, "a@b.c"))
+	assert.Nil(t, p.Embedded)
+	assert.Equal(t, &Extra{Email: NewColumn("a@b.c")}, removeColumnNames(p).Extra)
+
+	var decoded Parcel
+	assert.Nil(t, m.Unmarshal([]byte(`{"id":2,"parcel_weight":null,"extra_email":"d@e.f"}`), &decoded))
+	assert.Nil(t, decoded.Embedded)
+	assert.Equal(t, "d@e.f", decoded.Extra.Email.V)
+}
+
+type unexportedColumn struct {
+	ID   Column[uint64] `gorm:"column:id;primaryKey"`
+	name Column[string]
+}
+
+func TestUnexportedColumn(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	assert.PanicsWithError(t, "column field name of sqldb.unexportedColumn is unexported", func() {
+		NewModel[unexportedColumn](db)
+	})
+}
+
+type EmbeddedPrefixes struct {
+	Embedded `gorm:"embeddedPrefix:anonymous_"`
+	ExtraInner
+	Named             Embedded `gorm:"embeddedPrefix:named_"`
+	NamedTagged       Embedded `gorm:"embedded"`
+	NamedTaggedPrefix Embedded `gorm:"embedded;embeddedPrefix:tagged_"`
+	Nested            Extra    `gorm:"embedded;embeddedPrefix:outer_"`
+}
+
+func TestEmbeddedPrefix(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	cols := NewModel[EmbeddedPrefixes](db).Columns()
+
+	// anonymous with a prefix
+	assert.Equal(t, "anonymous_weight", cols.Embedded.Weight.GetColumnName().String())
+	// anonymous without a prefix
+	assert.Equal(t, "data", cols.ExtraInner.Data.GetColumnName().String())
+	// named without the embedded tag, the prefix is ignored
+	assert.Equal(t, "weight", cols.Named.Weight.GetColumnName().String())
+	// named with the embedded tag but without a prefix
+	assert.Equal(t, "weight", cols.NamedTagged.Weight.GetColumnName().String())
+	// named with the embedded tag and a prefix
+	assert.Equal(t, "tagged_weight", cols.NamedTaggedPrefix.Weight.GetColumnName().String())
+	// nested embedded structs
+	assert.Equal(t, "outer_email", cols.Nested.Email.GetColumnName().String())
+	assert.Equal(t, "outer_data", cols.Nested.Inner.Data.GetColumnName().String())
+}
+
+func removeColumnNames[T any](v T) T {
+	iterateFields(&v, false, func(fieldAddr reflect.Value, path []reflect.StructField) (bool, error) {
+		if !fieldAddr.IsValid() {
+			return false, nil
+		}
+		if setter, ok := fieldAddr.Interface().(columnNameSetter); ok {
+			setter.setColumnName("", "")
+			return false, nil
+		}
+		return true, nil
+	})
+	return v
+}
+
+func removeListColumnNames[T any](vs []T) []T {
+	return lo.Map(vs, func(v T, _ int) T { return removeColumnNames(v) })
+}
+
+type Document struct {
+	ID        Column[uint64] `gorm:"column:id;primaryKey"`
+	Title     Column[string]
+	CreatedBy Column[string]
+	UpdatedBy PtrColumn[string]
+}
+
+func TestAuditColumns(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	assert.Nil(t, db.AutoMigrate(Document{}))
+	defer db.Migrator().DropTable(Document{})
+	m := NewModel[Document](db, WithAuditColumns("created_by", "updated_by"))
+	cols := m.Columns()
+
+	doc := &Document{ID: NewColumn[uint64](1), Title: NewColumn("draft")}
+	assert.Nil(t, m.Create(WithAuditUser(ctx, "alice"), doc))
+	assert.Equal(t, "alice", doc.CreatedBy.V)
+	assert.Equal(t, "alice", *doc.UpdatedBy.V)
+	assert.Nil(t, m.Create(ctx, &Document{ID: NewColumn[uint64](2), Title: NewColumn("anonymous")}))
+
+	_, err := m.Query(cols.ID.In([]uint64{1, 2})).Update(WithAuditUser(ctx, "bob"), cols.Title.Update("final"))
+	assert.Nil(t, err, err)
+	docs, err := m.Query().Find(ctx, ListOptions{SortOptions: []SortOption{cols.ID.Sort(SortOrderAscending)}})
+	assert.Nil(t, err, err)
+	assert.Equal(t, []string{"alice", ""}, lo.Map(docs, func(d Document, _ int) string { return d.CreatedBy.V }))
+	assert.Equal(t, []string{"bob", "bob"}, lo.Map(docs, func(d Document, _ int) string { return *d.UpdatedBy.V }))
+
+	_, err = m.Query(cols.ID.EQ(uint64(1))).Update(WithAuditUser(ctx, "bob"), cols.UpdatedBy.Update("system"))
+	assert.Nil(t, err, err)
+	doc2, err := m.Query(cols.ID.EQ(uint64(1))).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, "system", *doc2.UpdatedBy.V)
+
+	users := NewModel[User](db)
+	_, err = m.Query(cols.ID.EQ(uint64(2))).UpdateFrom(WithAuditUser(ctx, "carol"), users,
+		[]OpOption{cols.ID.EQ(users.Columns().ID)}, cols.Title.Update(Ref(users.Columns().Name)))
+	assert.Nil(t, err, err)
+	doc2, err = m.Query(cols.ID.EQ(uint64(2))).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, "Jillian B Bennett", doc2.Title.V)
+	assert.Equal(t, "carol", *doc2.UpdatedBy.V)
+
+	assert.NotNil(t, m.Create(WithAuditUser(ctx, 42.5), &Document{ID: NewColumn[uint64](3)}))
+}
+
+type Profile struct {
+	ID       Column[uint64] `gorm:"column:id;primaryKey"`
+	UserName Column[string]
+	Status   Column[Status] `gorm:"serializer:json"`
+}
+
+func TestJoinSerializedColumns(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	assert.Nil(t, db.AutoMigrate(Profile{}))
+	defer db.Migrator().DropTable(Profile{})
+	users, profiles := NewModel[User](db), NewModel[Profile](db)
+	for _, p := range []*Profile{
+		{ID: NewColumn[uint64](1), UserName: u1.Name, Status: NewColumn(Status{Occupation: "Retired"})},
+		{ID: NewColumn[uint64](2), UserName: u3.Name, Status: NewColumn(Status{Occupation: "Principal"})},
+	} {
+		assert.Nil(t, profiles.Create(ctx, p))
+	}
+
+	joined := Join(ctx, users, profiles, NewJoinOptions(
+		[]ColumnNameGetter{users.Columns().ID, users.Columns().Status, profiles.Columns().ID, profiles.Columns().Status},
+		users.Columns().Name.EQ(profiles.Columns().UserName),
+	))
+	cols := joined.Columns()
+	results, err := joined.Query(cols.Right.Status.EQ(Status{Occupation: "Principal"})).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Equal(t, [][2]string{{"Teacher", "Principal"}}, lo.Map(results, func(r JoinedEntity[User, Profile], _ int) [2]string {
+		return [2]string{r.Left.Status.V.Occupation, r.Right.Status.V.Occupation}
+	}))
+
+	results, err = joined.Query().Find(ctx, ListOptions{SortOptions: []SortOption{cols.Left.ID.Sort(SortOrderAscending)}})
+	assert.Nil(t, err, err)
+	assert.Equal(t, [][2]string{{"Health Educator", "Retired"}, {"Teacher", "Principal"}}, lo.Map(results, func(r JoinedEntity[User, Profile], _ int) [2]string {
+		return [2]string{r.Left.Status.V.Occupation, r.Right.Status.V.Occupation}
+	}))
+}
+
+type OrderLine struct {
+	Sku Column[string] `json:"sku"`
+	Qty Column[int]    `json:"qty"`
+}
+
+type Order struct {
+	ID    Column[uint64] `gorm:"column:id;primaryKey"`
+	Lines []OrderLine    `gorm:"serializer:json"`
+}
+
+func TestSliceOfColumnStructs(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	assert.Nil(t, db.AutoMigrate(Order{}))
+	defer db.Migrator().DropTable(Order{})
+	m := NewModel[Order](db)
+	// the elements of slices are not walked, so they hold no columns of the model.
+	assert.Nil(t, m.Columns().Lines)
+	assert.Equal(t, []string{"id"}, lo.Map(m.ColumnNames(), func(c ColumnNameGetter, _ int) string { return c.GetColumnName().String() }))
+
+	order := &Order{ID: NewColumn[uint64](1), Lines: []OrderLine{
+		{Sku: NewColumn("apple"), Qty: NewColumn(2)},
+		{Sku: NewColumn("pear"), Qty: NewColumn(1)},
+	}}
+	assert.Nil(t, m.Create(ctx, order))
+	got, err := m.Query(m.Columns().ID.EQ(uint64(1))).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, *order, removeColumnNames(got))
+	assert.Equal(t, "", got.Lines[0].Sku.GetColumnName().String())
+}
+
+func TestWithColumnNamer(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	var parentNames []string
+	m := NewModel[User](db, WithColumnNamer(func(sf reflect.StructField, parents ...reflect.StructField) string {
+		if sf.Name == "Data" {
+			parentNames = lo.Map(parents, func(p reflect.StructField, _ int) string { return p.Name })
+		}
+		// sqlite compares column names case-insensitively.
+		return strings.ToUpper(db.NamingStrategy.ColumnName("", sf.Name))
+	}))
+	cols := m.Columns()
+	assert.Equal(t, "user_name", cols.Name.GetColumnName().Name)
+	assert.Equal(t, "AGE", cols.Age.GetColumnName().Name)
+	assert.Equal(t, "extra_DATA", cols.Extra.Inner.Data.GetColumnName().Name)
+	assert.Equal(t, []string{"Extra", "Inner"}, parentNames)
+
+	users, err := m.Query(cols.Age.GT(40)).Find(ctx, ListOptions{SortOptions: []SortOption{cols.Age.Sort(SortOrderAscending)}})
+	assert.Nil(t, err, err)
+	assert.Equal(t, []User{*u1, *u2}, removeListColumnNames(users))
+}
+
+type Person struct {
+	ID        Column[uint64] `gorm:"column:id;primaryKey"`
+	FirstName Column[string]
+	LastName  Column[string]
+	FullName  Column[string] `gorm:"->"`
+}
+
+func TestReadOnlyColumns(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	assert.Nil(t, db.Exec("CREATE TABLE people (id integer PRIMARY KEY, first_name text, last_name text, "+
+		"full_name text GENERATED ALWAYS AS (first_name || ' ' || last_name) VIRTUAL)").Error)
+	defer db.Migrator().DropTable(Person{})
+	m := NewModel[Person](db)
+	cols := m.Columns()
+	for _, p := range []*Person{
+		{ID: NewColumn[uint64](1), FirstName: NewColumn("Ada"), LastName: NewColumn("Lovelace")},
+		{ID: NewColumn[uint64](2), FirstName: NewColumn("Alan"), LastName: NewColumn("Turing")},
+	} {
+		assert.Nil(t, m.Create(ctx, p))
+	}
+
+	p, err := m.Query(cols.FullName.EQ("Alan Turing")).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(2), p.ID.V)
+	people, err := m.Query().Find(ctx, ListOptions{SortOptions: []SortOption{cols.FullName.Sort(SortOrderDescending)}})
+	assert.Nil(t, err, err)
+	assert.Equal(t, []string{"Alan Turing", "Ada Lovelace"}, lo.Map(people, func(p Person, _ int) string { return p.FullName.V }))
+
+	_, err = m.Query(cols.ID.EQ(uint64(1))).Update(ctx, cols.FullName.Update("Ada King"))
+	assert.EqualError(t, err, "the column full_name is read-only")
+	_, err = m.Query(cols.ID.EQ(uint64(1))).UpdateFrom(ctx, NewModel[User](db), []OpOption{cols.FirstName.EQ(NewModel[User](db).Columns().Name)},
+		cols.FullName.Update("Ada King"))
+	assert.EqualError(t, err, "the column full_name is read-only")
+	assert.EqualError(t, m.Upsert(ctx, &Person{ID: NewColumn[uint64](1)}, []ColumnNameGetter{cols.ID}, []ColumnNameGetter{cols.FullName}),
+		"the column full_name is read-only")
+
+	updated := p
+	updated.FirstName.V, updated.FullName.V = "Alan M", "Alan M Turing"
+	n, err := m.UpdateDiff(ctx, &p, &updated)
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(1), n)
+	p, err = m.Query(cols.ID.EQ(uint64(2))).Get(ctx)
+	assert.Nil(t, err, err)
+	assert.Equal(t, "Alan M Turing", p.FullName.V)
+}
+
+func TestWithSchema(t *testing.T) {
+	db, clean := initDB(t)
+	defer clean()
+	sqlDB, err := db.DB()
+	assert.Nil(t, err, err)
+	// attached databases are per connection.
+	sqlDB.SetMaxOpenConns(1)
+	assert.Nil(t, db.Exec("ATTACH DATABASE 'archive.db' AS archive").Error)
+	defer os.Remove("archive.db")
+	defer db.Exec("DETACH DATABASE archive")
+	var ddl string
+	assert.Nil(t, db.Raw("SELECT sql FROM sqlite_master WHERE name = 'users'").Scan(&ddl).Error)
+	assert.Nil(t, db.Exec(strings.Replace(ddl, "CREATE TABLE `users`", "CREATE TABLE archive.`users`", 1)).Error)
+
+	m := NewModel[User](db, WithSchema("archive"), WithQualifiedColumns())
+	cols := m.Columns()
+	assert.Equal(t, "archive.users", m.Table())
+	assert.Equal(t, "archive.users.age", cols.Age.Full())
+	assert.Nil(t, m.Create(ctx, NewUser(5, "William K Turner", 80, "", 60, "Retired", "")))
+	_, err = m.Query(cols.ID.EQ(uint64(5))).Update(ctx, cols.Age.Update(81))
+	assert.Nil(t, err, err)
+
+	users, total, err := m.Query(cols.Age.GT(40)).List(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Equal(t, uint64(1), total)
+	assert.Equal(t, 81, users[0].Age.V)
+
+	relations := NewModel[Relation](db)
+	joined := Join(ctx, m, relations, NewJoinOptions(
+		[]ColumnNameGetter{cols.ID, relations.Columns().Name},
+		cols.Name.EQ(relations.Columns().UserName),
+	))
+	results, err := joined.Query(joined.Columns().Left.Age.GT(40)).Find(ctx, ListOptions{})
+	assert.Nil(t, err, err)
+	assert.Equal(t, [][2]any{{uint64(5), "relation2"}}, lo.Map(results, func(r JoinedEntity[User, Relation], _ int) [2]any {
+		return [2]any{r.Left.ID.V, r.Right.Name.V}
+	}))
 }
@@ -0,0 +1,89 @@
+package sqldb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Pager reads the entities of an executor page by page with keyset pagination, each page is read by a single query
+// which resumes after the value of the cursor column in the last entity of the previous page:
+//
+//	p := m.Query(filters...).Pager(100, cols.ID)
+//	for p.Next(ctx) {
+//		handle(p.Page())
+//	}
+//	if err := p.Err(); err != nil {
+//		return err
+//	}
+//
+// The cursor column must be unique and not NULL, otherwise entities sharing the value of the last one are skipped.
+type Pager[T any] struct {
+	executor Executor[T]
+	pageSize uint64
+	column   ColumnNameGetter
+	value    func(T) (any, error)
+
+	cursor  any
+	started bool
+	done    bool
+	page    []T
+	err     error
+}
+
+// NewPager returns a Pager which reads the entities of e in ascending order of col, value returns the value of col
+// in an entity. It is used by implementations of Executor.Pager.
+func NewPager[T any](e Executor[T], pageSize uint64, col ColumnNameGetter, value func(T) (any, error)) *Pager[T] {
+	return &Pager[T]{
+		executor: e,
+		pageSize: pageSize,
+		column:   col,
+		value:    value,
+	}
+}
+
+// Next reads the next page, it returns false if there are no more entities or an error occurs.
+func (p *Pager[T]) Next(ctx context.Context) bool {
+	if p.done || p.err != nil {
+		return false
+	}
+	if p.pageSize == 0 {
+		p.err = errors.New("page size must be positive")
+		return false
+	}
+	e := p.executor
+	if p.started {
+		cursor := NewOpQueryOption(p.column.GetColumnName(), OpGt, p.cursor)
+		e = e.Apply(func() []FilterOption { return []FilterOption{cursor} })
+	}
+	page, err := e.Find(ctx, ListOptions{
+		Limit:       p.pageSize,
+		SortOptions: []SortOption{NewSortOption(p.column.GetColumnName(), SortOrderAscending)},
+	})
+	if err != nil {
+		p.err = err
+		return false
+	}
+	p.started, p.page, p.done = true, page, uint64(len(page)) < p.pageSize
+	if len(page) == 0 {
+		return false
+	}
+	if !p.done {
+		if p.cursor, err = p.value(page[len(page)-1]); err != nil {
+			p.err = err
+		} else if err := checkOpValue(OpGt, p.cursor); err != nil {
+			p.err = fmt.Errorf("invalid cursor of the column %s: %w", p.column.GetColumnName(), err)
+		}
+	}
+	return true
+}
+
+// Page returns the entities read by the last call of Next.
+func (p *Pager[T]) Page() []T {
+	return p.page
+}
+
+// Err returns the error which stopped the pager.
+func (p *Pager[T]) Err() error {
+	return p.err
+}
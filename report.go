@@ -0,0 +1,256 @@
+package sqldb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+)
+
+// AggregateFunc is an SQL aggregate function.
+type AggregateFunc string
+
+const (
+	AggregateCount AggregateFunc = "COUNT"
+	AggregateSum   AggregateFunc = "SUM"
+	AggregateAvg   AggregateFunc = "AVG"
+	AggregateMin   AggregateFunc = "MIN"
+	AggregateMax   AggregateFunc = "MAX"
+)
+
+// Aggregate selects Func over Column as the column As of report rows or the key of results of ListWithAggregate,
+// a nil Column counts rows with COUNT(*).
+type Aggregate struct {
+	Func   AggregateFunc
+	Column ColumnNameGetter
+	As     ColumnNameGetter
+}
+
+// Bucket is the half-open range [From, To) of a histogram, a nil From or To leaves the range unbounded on that side.
+type Bucket struct {
+	From any
+	To   any
+}
+
+// BucketCount is the number of entities in a bucket of a histogram.
+type BucketCount struct {
+	Bucket
+	Count uint64
+}
+
+// AggregateExpr is an aggregate function over a column of the model, it is compared with values in the HAVING clause
+// of reports.
+type AggregateExpr struct {
+	fn     AggregateFunc
+	column ColumnNameGetter
+}
+
+// Count counts the non-NULL values of col, a nil col counts rows with COUNT(*).
+func Count(col ColumnNameGetter) AggregateExpr {
+	return AggregateExpr{fn: AggregateCount, column: col}
+}
+
+func Sum(col ColumnNameGetter) AggregateExpr {
+	return AggregateExpr{fn: AggregateSum, column: col}
+}
+
+func Avg(col ColumnNameGetter) AggregateExpr {
+	return AggregateExpr{fn: AggregateAvg, column: col}
+}
+
+func Min(col ColumnNameGetter) AggregateExpr {
+	return AggregateExpr{fn: AggregateMin, column: col}
+}
+
+func Max(col ColumnNameGetter) AggregateExpr {
+	return AggregateExpr{fn: AggregateMax, column: col}
+}
+
+func (a AggregateExpr) EQ(v any) HavingOption {
+	return newHavingOption(a, OpEq, v)
+}
+
+func (a AggregateExpr) NE(v any) HavingOption {
+	return newHavingOption(a, OpNe, v)
+}
+
+func (a AggregateExpr) GT(v any) HavingOption {
+	return newHavingOption(a, OpGt, v)
+}
+
+func (a AggregateExpr) LT(v any) HavingOption {
+	return newHavingOption(a, OpLt, v)
+}
+
+func (a AggregateExpr) GTE(v any) HavingOption {
+	return newHavingOption(a, OpGte, v)
+}
+
+func (a AggregateExpr) LTE(v any) HavingOption {
+	return newHavingOption(a, OpLte, v)
+}
+
+// sql returns the aggregate function call.
+func (a AggregateExpr) sql(qualified bool) string {
+	return aggregateSQL(qualified, a.fn, a.column)
+}
+
+// HavingOption compares an aggregate with a value in the HAVING clause of a report, it is built by the comparison
+// methods of AggregateExpr. Unlike FilterOption, it can not be used as a WHERE condition.
+type HavingOption struct {
+	aggregate AggregateExpr
+	op        QueryOp
+	value     any
+}
+
+func newHavingOption(a AggregateExpr, op QueryOp, v any) HavingOption {
+	if err := checkOpValue(op, v); err != nil {
+		panic(fmt.Sprintf("invalid having option on %s: %s", a.sql(false), err))
+	}
+	return HavingOption{aggregate: a, op: op, value: v}
+}
+
+// aggregateSQL returns the call of fn over col, a nil col is replaced with *.
+func aggregateSQL(qualified bool, fn AggregateFunc, col ColumnNameGetter) string {
+	arg := lo.TernaryF(col == nil, func() string { return "*" }, func() string { return getColumnName(qualified, col) })
+	return fmt.Sprintf("%s(%s)", fn, arg)
+}
+
+// aggregateZero returns the zero value of the report column of agg if the aggregate is NULL over no rows, e.g. SUM,
+// and the column can not hold NULL, so that empty groups are scanned as zero values.
+func aggregateZero(agg Aggregate) (any, bool) {
+	typed, ok := agg.As.(interface{ reflectType() reflect.Type })
+	if !ok || agg.Func == AggregateCount {
+		return nil, false
+	}
+	switch rt := typed.reflectType(); rt.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String, reflect.Bool:
+		return reflect.Zero(rt).Interface(), true
+	}
+	return nil, false
+}
+
+// Report builds an aggregate query over the entities of a model and scans the result rows into R,
+// a struct whose Column fields are named after the group by columns and the aliases of aggregates, for example:
+//
+//	type AgeStat struct {
+//		Age   sqldb.Column[int]
+//		Count sqldb.Column[uint64]
+//	}
+//
+//	r := sqldb.NewReport[User, AgeStat](m, cols.Age.GT(18))
+//	rc := r.Columns()
+//	stats, err := r.GroupBy(cols.Age).
+//		Aggregate(sqldb.Aggregate{Func: sqldb.AggregateCount, As: rc.Count}).
+//		Having(rc.Count.GT(uint64(1))).
+//		Find(ctx, sqldb.ListOptions{SortOptions: []sqldb.SortOption{rc.Count.Sort(sqldb.SortOrderDescending)}, Limit: 10})
+//
+// The aggregate query is wrapped as a subquery, so the HAVING filters and list options refer to the columns of R.
+type Report[T, R any] struct {
+	model      Model[T]
+	rows       Model[R]
+	filters    []FilterOption
+	groupBy    []ColumnNameGetter
+	aggregates []Aggregate
+	having     []FilterOption
+	havingAggs []HavingOption
+}
+
+// NewReport returns a report over the entities of m which match filters, m must be created by NewModel or joins.
+func NewReport[T, R any](m Model[T], filters ...FilterOption) Report[T, R] {
+	return Report[T, R]{
+		model:   m,
+		rows:    NewModel[R](m.DB(context.Background())),
+		filters: filters,
+	}
+}
+
+// Columns returns the columns of report rows which are used in HAVING filters and sort options.
+func (r Report[T, R]) Columns() R {
+	return r.rows.Columns()
+}
+
+// GroupBy groups entities by cols, which are selected under their names without table names.
+func (r Report[T, R]) GroupBy(cols ...ColumnNameGetter) Report[T, R] {
+	r.groupBy = append(r.groupBy[:len(r.groupBy):len(r.groupBy)], cols...)
+	return r
+}
+
+func (r Report[T, R]) Aggregate(aggregates ...Aggregate) Report[T, R] {
+	r.aggregates = append(r.aggregates[:len(r.aggregates):len(r.aggregates)], aggregates...)
+	return r
+}
+
+// Having filters the report rows, the filter options must be built from the columns of R.
+// Use HavingAggregate to filter groups by aggregates which are not selected into report rows.
+func (r Report[T, R]) Having(filters ...FilterOption) Report[T, R] {
+	r.having = append(r.having[:len(r.having):len(r.having)], filters...)
+	return r
+}
+
+// HavingAggregate filters the groups by aggregates over the columns of the model, for example:
+//
+//	r.GroupBy(cols.Age).HavingAggregate(sqldb.Count(cols.ID).GT(1), sqldb.Max(cols.Weight).LTE(100))
+func (r Report[T, R]) HavingAggregate(opts ...HavingOption) Report[T, R] {
+	r.havingAggs = append(r.havingAggs[:len(r.havingAggs):len(r.havingAggs)], opts...)
+	return r
+}
+
+// Find runs the report, opts sort and paginate the report rows.
+func (r Report[T, R]) Find(ctx context.Context, opts ListOptions) ([]R, error) {
+	e, ok := r.model.Query(r.filters...).(executor[T])
+	if !ok {
+		return nil, errors.New("reports are only supported by models created by NewModel or joins")
+	}
+	if len(r.groupBy)+len(r.aggregates) == 0 {
+		return nil, errors.New("empty report")
+	}
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+	e.preloads = nil
+	db, err := e.queryDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		selects = make([]string, 0, len(r.groupBy)+len(r.aggregates))
+		vars    []any
+	)
+	for _, col := range r.groupBy {
+		name := col.GetColumnName().Name
+		column := getColumnName(e.qualified(), col)
+		selects = append(selects, fmt.Sprintf("%s AS %s", column, db.Statement.Quote(name[strings.LastIndex(name, ".")+1:])))
+		db = db.Group(column)
+	}
+	for _, agg := range r.aggregates {
+		if agg.As == nil {
+			return nil, fmt.Errorf("alias of aggregate %s is missing", agg.Func)
+		}
+		expr := aggregateSQL(e.qualified(), agg.Func, agg.Column)
+		if zero, ok := aggregateZero(agg); ok {
+			expr, vars = fmt.Sprintf("COALESCE(%s, ?)", expr), append(vars, zero)
+		}
+		selects = append(selects, fmt.Sprintf("%s AS %s", expr, db.Statement.Quote(agg.As.GetColumnName().Name)))
+	}
+	if len(r.havingAggs) > 0 {
+		conditions := make([]string, 0, len(r.havingAggs))
+		values := make([]any, 0, len(r.havingAggs))
+		for _, opt := range r.havingAggs {
+			conditions = append(conditions, fmt.Sprintf("%s %s ?", opt.aggregate.sql(e.qualified()), dialectQueryOp(db, opt.op)))
+			values = append(values, opt.value)
+		}
+		db = db.Having(strings.Join(conditions, " AND "), values...)
+	}
+	sub := db.Select(strings.Join(selects, ", "), vars...)
+	// sub is only rendered into the query of report rows, which runs on the db of the model or the transaction in ctx.
+	rows := NewModel[R](e.db, WithDBInitialFunc(func(db *gorm.DB) *gorm.DB {
+		return db.Table("(?) AS report", sub)
+	}))
+	return rows.Query(r.having...).Find(ctx, opts)
+}
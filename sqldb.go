@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/samber/lo"
 	"github.com/samber/mo"
@@ -20,6 +21,7 @@ const (
 	FilterOptionTypeOpQuery    FilterOptionType = "OpQuery"
 	FilterOptionTypeRangeQuery FilterOptionType = "RangeQuery"
 	FilterOptionTypeFuzzyQuery FilterOptionType = "FuzzyQuery"
+	FilterOptionTypeGroup      FilterOptionType = "Group"
 )
 
 type FilterOption interface {
@@ -35,6 +37,13 @@ const (
 	OpLt  QueryOp = "<"
 	OpGte QueryOp = ">="
 	OpLte QueryOp = "<="
+	// OpEqNullSafe is the null-safe equal operator, NULL equals to NULL when comparing with it.
+	// It is translated to "IS NOT DISTINCT FROM" on dialects other than MySQL.
+	OpEqNullSafe QueryOp = "<=>"
+	// OpJSONContains is the JSONB containment operator of Postgres, the value is marshaled into JSON before comparing.
+	OpJSONContains QueryOp = "@>"
+	// OpHasFlag matches integer columns having all bits of the value set, it is translated to `column & ? = ?`.
+	OpHasFlag QueryOp = "&"
 )
 
 // Option wraps basic methods of options.
@@ -96,6 +105,12 @@ func (opt valuesOption[T]) GetValues() []any {
 type JoinOptions struct {
 	SelectedColumns []ColumnNameGetter
 	Conditions      []OpOption
+	// RawConditions are raw SQL fragments which are wrapped in parentheses and added to the ON clause with AND,
+	// column names in them should be qualified with table names.
+	RawConditions []clause.Expr
+	// Filters are added to the WHERE clause of every query of the joined model, they can be built from the columns
+	// of both models.
+	Filters []FilterOption
 }
 
 func NewJoinOptions(selectedColumns []ColumnNameGetter, conditions ...OpOption) JoinOptions {
@@ -105,13 +120,35 @@ func NewJoinOptions(selectedColumns []ColumnNameGetter, conditions ...OpOption)
 	}
 }
 
+// WithRawConditions adds raw conditions to the ON clause, for example:
+//
+//	opts.WithRawConditions(gorm.Expr("relations.age > ?", 5), gorm.Expr("relations.name IS NULL OR relations.name != ''"))
+func (opts JoinOptions) WithRawConditions(conditions ...clause.Expr) JoinOptions {
+	opts.RawConditions = append(opts.RawConditions[:len(opts.RawConditions):len(opts.RawConditions)], conditions...)
+	return opts
+}
+
+// WithFilters adds filters to every query of the joined model, for example:
+//
+//	opts.WithFilters(users.Columns().Age.GT(18), relations.Columns().Name.NE(""))
+func (opts JoinOptions) WithFilters(filters ...FilterOption) JoinOptions {
+	opts.Filters = append(opts.Filters[:len(opts.Filters):len(opts.Filters)], filters...)
+	return opts
+}
+
 type OpJoinOption interface {
 	GetLeftColumnName() ColumnName
 	GetRightColumnName() ColumnName
 	QueryOp() QueryOp
 }
 
+// NewOpJoinOption returns an option which compares the column left with the column right using op,
+// it panics if op is not a known query operator.
 func NewOpJoinOption(left ColumnName, op QueryOp, right ColumnName) OpOption {
+	mustValidQueryOp(left, op)
+	if op == OpHasFlag {
+		panic(fmt.Sprintf("operator %s can not compare the column %s with another column", op, left))
+	}
 	return OpOption{
 		Either: mo.Left[OpJoinOption, OpQueryOption](opJoinOption{
 			left:  left,
@@ -151,7 +188,13 @@ type opQueryOption[T any] struct {
 	op QueryOp
 }
 
+// NewOpQueryOption returns an option which compares the column with v using op,
+// it panics if op is not a known query operator or v can not be compared by op, such as ordering booleans or NULL.
 func NewOpQueryOption[T any](name ColumnName, op QueryOp, v T) OpOption {
+	mustValidQueryOp(name, op)
+	if err := checkOpValue(op, v); err != nil {
+		panic(fmt.Sprintf("invalid query option on the column %s: %s", name, err))
+	}
 	return OpOption{
 		Either: mo.Right[OpJoinOption, OpQueryOption](
 			opQueryOption[T]{
@@ -161,6 +204,95 @@ func NewOpQueryOption[T any](name ColumnName, op QueryOp, v T) OpOption {
 	}
 }
 
+// mustValidQueryOp panics if op is not one of the known query operators,
+// so that a mistaken operator is reported where the option is built rather than where the query runs.
+func mustValidQueryOp(name ColumnName, op QueryOp) {
+	switch op {
+	case OpEq, OpNe, OpGt, OpLt, OpGte, OpLte, OpEqNullSafe, OpJSONContains, OpHasFlag:
+	default:
+		panic(fmt.Sprintf("invalid query operator %q on the column %s", op, name))
+	}
+}
+
+// checkOpValue rejects values which can not be ordered when op is an ordering operator,
+// and values other than unsigned integers when op is OpHasFlag.
+func checkOpValue(op QueryOp, v any) error {
+	if op == OpHasFlag {
+		if _, ok := v.(uint64); !ok {
+			return fmt.Errorf("operator %s can only be applied to masks of type uint64, not %T", op, v)
+		}
+		return nil
+	}
+	if op != OpGt && op != OpLt && op != OpGte && op != OpLte {
+		return nil
+	}
+	switch v.(type) {
+	case clause.Expr, driver.Valuer:
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Invalid, reflect.Pointer:
+		return fmt.Errorf("operator %s can not be applied to NULL", op)
+	case reflect.Bool, reflect.Map, reflect.Chan, reflect.Func:
+		return fmt.Errorf("operator %s can not be applied to values of type %s", op, rv.Type())
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("operator %s can not be applied to values of type %s", op, rv.Type())
+		}
+	}
+	return nil
+}
+
+// Quantifier is the quantifier of a comparison between a column and a list of values.
+type Quantifier string
+
+const (
+	// QuantifierAny matches if the comparison holds for any of the values.
+	QuantifierAny Quantifier = "ANY"
+	// QuantifierAll matches if the comparison holds for all of the values.
+	QuantifierAll Quantifier = "ALL"
+)
+
+// QuantifiedValues is the value of an OpQueryOption which compares the column with each of Values.
+// It is translated to the array comparison `column op ANY(array)` or `column op ALL(array)` on Postgres, and to IN,
+// NOT IN or comparisons combined with OR and AND on other dialects. Comparing with ANY of no values never matches,
+// while comparing with ALL of no values always matches.
+type QuantifiedValues struct {
+	Quantifier Quantifier
+	Values     []any
+}
+
+// RawValue is the value of an OpQueryOption which is compared with the stored value of the column as is,
+// it is not serialized by the serializer of the column.
+type RawValue struct {
+	V any
+}
+
+// NewQuantifiedQueryOption returns an option which compares the column with values using op and quantifier,
+// it panics if op is not one of the comparison operators or some of values can not be compared by op.
+func NewQuantifiedQueryOption[T any](name ColumnName, op QueryOp, quantifier Quantifier, values []T) OpOption {
+	switch op {
+	case OpEq, OpNe, OpGt, OpLt, OpGte, OpLte:
+	default:
+		panic(fmt.Sprintf("invalid quantified query operator %q on the column %s", op, name))
+	}
+	if quantifier != QuantifierAny && quantifier != QuantifierAll {
+		panic(fmt.Sprintf("invalid quantifier %q on the column %s", quantifier, name))
+	}
+	q := QuantifiedValues{Quantifier: quantifier, Values: make([]any, len(values))}
+	for i, v := range values {
+		if err := checkOpValue(op, v); err != nil {
+			panic(fmt.Sprintf("invalid query option on the column %s: %s", name, err))
+		}
+		q.Values[i] = v
+	}
+	return NewOpQueryOption(name, op, q)
+}
+
 func (opt opQueryOption[T]) QueryOp() QueryOp {
 	return opt.op
 }
@@ -174,6 +306,9 @@ type OpOption struct {
 }
 
 func (opt OpOption) GetFilterOptionType() FilterOptionType {
+	if opt.IsLeft() {
+		return FilterOptionTypeOpQuery
+	}
 	return opt.MustRight().(FilterOption).GetFilterOptionType()
 }
 
@@ -209,23 +344,177 @@ func (opt rangeQueryOption[T]) GetFilterOptionType() FilterOptionType {
 type FuzzyQueryOption interface {
 	FilterOption
 	ValuesOption
+	// CaseSensitive reports whether the patterns are matched case-sensitively regardless of the collation of the column.
+	CaseSensitive() bool
 }
 
 // fuzzyQueryOption implements the FuzzyQueryOption.
 type fuzzyQueryOption[T any] struct {
 	valuesOption[T]
+	caseSensitive bool
 }
 
+// NewFuzzyQueryOption returns a FuzzyQueryOption, it panics if T is not a string type since LIKE on other columns,
+// e.g. timestamps, matches their text forms which is never meant.
 func NewFuzzyQueryOption[T any](name ColumnName, values []T) FuzzyQueryOption {
+	mustStringValues[T](name)
 	return fuzzyQueryOption[T]{
 		valuesOption: newValuesOption(name, values),
 	}
 }
 
+// NewCaseSensitiveFuzzyQueryOption returns a FuzzyQueryOption which matches the patterns case-sensitively.
+func NewCaseSensitiveFuzzyQueryOption[T any](name ColumnName, values []T) FuzzyQueryOption {
+	mustStringValues[T](name)
+	return fuzzyQueryOption[T]{
+		valuesOption:  newValuesOption(name, values),
+		caseSensitive: true,
+	}
+}
+
+// mustStringValues panics if T is not a string type, so that fuzzy queries are rejected where they are built.
+func mustStringValues[T any](name ColumnName) {
+	rt := reflect.TypeOf((*T)(nil)).Elem()
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.String {
+		panic(fmt.Sprintf("fuzzy queries are only supported by string columns, the column %s is of type %s", name, rt))
+	}
+}
+
+func (opt fuzzyQueryOption[T]) CaseSensitive() bool {
+	return opt.caseSensitive
+}
+
 func (opt fuzzyQueryOption[T]) GetFilterOptionType() FilterOptionType {
 	return FilterOptionTypeFuzzyQuery
 }
 
+// Scope is a reusable bundle of filter options, e.g.
+//
+//	func OnlyActive() []FilterOption { return []FilterOption{cols.Status.EQ("active")} }
+//
+// scopes are applied to queries by Executor.Apply.
+type Scope func() []FilterOption
+
+// GroupOp is the logical operator which combines the filter options of a group.
+type GroupOp string
+
+const (
+	GroupOpAnd GroupOp = "AND"
+	GroupOpOr  GroupOp = "OR"
+	// GroupOpNot combines the filter options with AND and negates the result.
+	GroupOpNot GroupOp = "NOT"
+)
+
+// GroupOption represents a group of filter options combined by a logical operator, groups can be nested.
+type GroupOption interface {
+	FilterOption
+	GroupOp() GroupOp
+	// GetFilterOptions returns the filter options in the group.
+	GetFilterOptions() []FilterOption
+}
+
+// groupOption implements the GroupOption.
+type groupOption struct {
+	op   GroupOp
+	opts []FilterOption
+}
+
+func NewGroupOption(op GroupOp, opts ...FilterOption) GroupOption {
+	return groupOption{op: op, opts: opts}
+}
+
+// And returns a group which matches data that match all the filter options.
+func And(opts ...FilterOption) GroupOption {
+	return NewGroupOption(GroupOpAnd, opts...)
+}
+
+// Or returns a group which matches data that match any of the filter options.
+func Or(opts ...FilterOption) GroupOption {
+	return NewGroupOption(GroupOpOr, opts...)
+}
+
+// Not returns a group which matches data that do not match all the filter options, i.e. NOT (opt1 AND opt2 ...).
+func Not(opts ...FilterOption) GroupOption {
+	return NewGroupOption(GroupOpNot, opts...)
+}
+
+func (opt groupOption) GetFilterOptionType() FilterOptionType {
+	return FilterOptionTypeGroup
+}
+
+func (opt groupOption) GroupOp() GroupOp {
+	return opt.op
+}
+
+func (opt groupOption) GetFilterOptions() []FilterOption {
+	return opt.opts
+}
+
+// RowValuesBuilder builds comparisons between a row of columns and a row of values, see RowValues.
+type RowValuesBuilder struct {
+	columns []ColumnNameGetter
+}
+
+// RowValues returns a builder of lexicographical comparisons between cols and values, which are useful for keyset
+// pagination on multiple columns, e.g. RowValues(cols.CreatedAt, cols.ID).GT(createdAt, id) is rendered as
+// `(created_at, id) > (?, ?)` on Postgres, MySQL and SQLite. The comparisons are groups of the expanded conditions
+// `created_at > ? OR (created_at = ? AND id > ?)`, which are used on other dialects and by DescribeFilters.
+func RowValues(cols ...ColumnNameGetter) RowValuesBuilder {
+	return RowValuesBuilder{columns: cols}
+}
+
+func (b RowValuesBuilder) GT(values ...any) GroupOption {
+	return b.compare(OpGt, values)
+}
+
+func (b RowValuesBuilder) GTE(values ...any) GroupOption {
+	return b.compare(OpGte, values)
+}
+
+func (b RowValuesBuilder) LT(values ...any) GroupOption {
+	return b.compare(OpLt, values)
+}
+
+func (b RowValuesBuilder) LTE(values ...any) GroupOption {
+	return b.compare(OpLte, values)
+}
+
+// compare returns the comparison between the columns and values by op, it panics if the numbers of them differ.
+func (b RowValuesBuilder) compare(op QueryOp, values []any) GroupOption {
+	if len(b.columns) == 0 || len(values) != len(b.columns) {
+		panic(fmt.Sprintf("%d values can not be compared with %d columns", len(values), len(b.columns)))
+	}
+	// the columns before the last one are compared strictly, (a, b) >= (x, y) is a > x OR (a = x AND b >= y).
+	strict := lo.Ternary(op == OpGte, OpGt, lo.Ternary(op == OpLte, OpLt, op))
+	members := make([]FilterOption, len(b.columns))
+	for i, col := range b.columns {
+		conditions := make([]FilterOption, 0, i+1)
+		for j := 0; j < i; j++ {
+			conditions = append(conditions, NewOpQueryOption(b.columns[j].GetColumnName(), OpEq, values[j]))
+		}
+		conditions = append(conditions, NewOpQueryOption(col.GetColumnName(), lo.Ternary(i == len(b.columns)-1, op, strict), values[i]))
+		members[i] = And(conditions...)
+	}
+	return rowValuesOption{
+		groupOption: groupOption{op: GroupOpOr, opts: members},
+		columns:     b.columns,
+		op:          op,
+		values:      values,
+	}
+}
+
+// rowValuesOption is the comparison between a row of columns and a row of values, it is also a group of the
+// expanded comparisons.
+type rowValuesOption struct {
+	groupOption
+	columns []ColumnNameGetter
+	op      QueryOp
+	values  []any
+}
+
 // UpdateOption represents an update operation that updates the target column with given value.
 type UpdateOption interface {
 	Option
@@ -276,11 +565,40 @@ func (opt sortOption) GetSortOrder() SortOrder {
 	return opt.order
 }
 
+// ValuesSortOption sorts data by the positions of the column values in a list, e.g. to keep the order of an IN list.
+// Data whose values are not in the list are sorted after the others.
+type ValuesSortOption interface {
+	SortOption
+	ValuesOption
+}
+
+// valuesSortOption implements the ValuesSortOption.
+type valuesSortOption[T any] struct {
+	valuesOption[T]
+}
+
+func NewValuesSortOption[T any](name ColumnName, values []T) ValuesSortOption {
+	return valuesSortOption[T]{valuesOption: newValuesOption(name, values)}
+}
+
+func (opt valuesSortOption[T]) GetSortOrder() SortOrder {
+	return SortOrderAscending
+}
+
 // ListOptions contains options and parameters that related to data listing.
 type ListOptions struct {
 	Offset      uint64
 	Limit       uint64
 	SortOptions []SortOption
+	// Columns restricts the selected columns, the other columns of the listed entities are left zero.
+	// It overrides JoinOptions.SelectedColumns of joined models. The total of List is not affected.
+	Columns []ColumnNameGetter
+	// WindowTotal makes List compute the total with COUNT(*) OVER () in the same query instead of a separate
+	// COUNT query, the dialect must support window functions.
+	WindowTotal bool
+	// AsOf reads the data as of the time with `AS OF SYSTEM TIME`, which serves stale but cheap reads from followers.
+	// It is ignored unless the model is created with WithAsOfSystemTime.
+	AsOf time.Time
 }
 
 // columnNameSetter sets the column name of a filed
@@ -320,11 +638,31 @@ func (cn ColumnName) GetColumnName() ColumnName {
 	return cn
 }
 
+// As returns the column selected with alias by SelectedColumns of joins and ListOptions.Columns, so that the results
+// can be scanned into structs whose column names differ, e.g. by Rows and ListRaw. Joined entities are still scanned
+// from the aliased columns, while other models scan entities by gorm which does not know the aliases.
+// Filters and sorts on the returned column refer to the column itself.
+func (cn ColumnName) As(alias string) ColumnNameGetter {
+	return aliasedColumn{ColumnNameGetter: cn, alias: alias}
+}
+
 func (cn *ColumnName) setColumnName(table, name string) {
 	cn.table = table
 	cn.Name = name
 }
 
+// ColumnRef wraps a column name so that it is compared as a column rather than a literal value, for example:
+//
+//	cols.CreatedAt.LT(sqldb.Ref(cols.UpdatedAt))
+type ColumnRef struct {
+	ColumnName
+}
+
+// Ref returns a ColumnRef which refers to the given column.
+func Ref(getter ColumnNameGetter) ColumnRef {
+	return ColumnRef{ColumnName: getter.GetColumnName()}
+}
+
 type ColumnValue[T any] struct {
 	V T
 }
@@ -334,11 +672,7 @@ func (cv ColumnValue[T]) reflectType() reflect.Type {
 }
 
 func (cv ColumnValue[T]) reflectValue() reflect.Value {
-	rv := reflect.ValueOf(*new(T))
-	if rv.Kind() == reflect.Ptr {
-		return reflect.ValueOf(*new(*T))
-	}
-	return rv
+	return reflect.ValueOf(new(T)).Elem()
 }
 
 func (cv ColumnValue[T]) convertFrom(v any) (res T, err error) {
@@ -349,6 +683,12 @@ func (cv ColumnValue[T]) convertFrom(v any) (res T, err error) {
 	if valuer, ok := v.(interface{ reflectValue() reflect.Value }); ok {
 		rrv = valuer.reflectValue()
 	}
+	if !rrv.CanConvert(rt) && rt.Kind() == reflect.Pointer && rrv.CanConvert(rt.Elem()) {
+		// values of pointer columns may be given without the pointer
+		ptr := reflect.New(rt.Elem())
+		ptr.Elem().Set(rrv.Convert(rt.Elem()))
+		rrv = ptr
+	}
 	if !rrv.CanConvert(rt) {
 		err = fmt.Errorf("unable to convert value of type %s to the column type %s", rrv.Type(), rt)
 		return
@@ -371,8 +711,12 @@ func (cv ColumnValue[T]) Value() (driver.Value, error) {
 }
 
 // Scan implements the Scanner interface.
+// The error names both the type of src and the type of the column, the name of the column is added by callers.
 func (cv *ColumnValue[T]) Scan(src any) error {
-	return sql.ConvertAssign(&cv.V, src)
+	if err := sql.ConvertAssign(&cv.V, src); err != nil {
+		return fmt.Errorf("failed to convert %T into %s: %w", src, reflect.TypeOf(&cv.V).Elem(), err)
+	}
+	return nil
 }
 
 // CreateClauses implements the CreateClausesInterface interface from GORM.
@@ -413,6 +757,22 @@ type columnBase[T any] struct {
 }
 
 func (c columnBase[T]) buildOpOption(value any, op QueryOp) (OpOption, error) {
+	if ref, ok := value.(ColumnRef); ok {
+		return NewOpJoinOption(c.ColumnName, op, ref.GetColumnName()), nil
+	}
+	if err := checkOpValue(op, value); err != nil {
+		return OpOption{}, fmt.Errorf("failed to build query options for the column %s: %w", c.ColumnName, err)
+	}
+	if rv := reflect.ValueOf(value); value == nil || rv.Kind() == reflect.Pointer && rv.IsNil() {
+		// comparing with NULL by other operators is never true, which silently matches no rows.
+		if op != OpEqNullSafe {
+			return OpOption{}, fmt.Errorf("failed to build query options for the column %s: operator %s can not be applied to NULL, use EqNullSafe instead", c.ColumnName, op)
+		}
+		return NewOpQueryOption[any](c.ColumnName, op, nil), nil
+	}
+	if expr, ok := value.(clause.Expr); ok {
+		return NewOpQueryOption(c.ColumnName, op, expr), nil
+	}
 	v, err := c.convertFrom(value)
 	if err != nil {
 		return OpOption{}, fmt.Errorf("failed to build query options for the column %s: %w", c.ColumnName, err)
@@ -447,6 +807,53 @@ func (c columnBase[T]) LTE(value any) OpOption {
 	return lo.Must(c.buildOpOption(value, OpLte))
 }
 
+// EqNullSafe is like EQ but NULL matches NULL, value can be nil.
+func (c columnBase[T]) EqNullSafe(value any) OpOption {
+	return lo.Must(c.buildOpOption(value, OpEqNullSafe))
+}
+
+// JSONBContains finds rows whose JSONB value of the column contains the JSON encoding of value.
+// It is only supported by Postgres, value can be a part of the column value, e.g. a map with some of the keys.
+func (c columnBase[T]) JSONBContains(value any) OpOption {
+	return NewOpQueryOption(c.ColumnName, OpJSONContains, value)
+}
+
+// RawEQ finds rows whose stored value of the column equals raw, the serializer of the column is bypassed, e.g. raw is
+// the JSON string of a column with `serializer:json`.
+func (c columnBase[T]) RawEQ(raw any) OpOption {
+	return NewOpQueryOption(c.ColumnName, OpEq, RawValue{V: raw})
+}
+
+// EQTyped is like EQ but the type of value is checked at compile time.
+func (c columnBase[T]) EQTyped(value T) OpOption {
+	return NewOpQueryOption(c.ColumnName, OpEq, value)
+}
+
+// NETyped is like NE but the type of value is checked at compile time.
+func (c columnBase[T]) NETyped(value T) OpOption {
+	return NewOpQueryOption(c.ColumnName, OpNe, value)
+}
+
+// GTTyped is like GT but the type of value is checked at compile time.
+func (c columnBase[T]) GTTyped(value T) OpOption {
+	return NewOpQueryOption(c.ColumnName, OpGt, value)
+}
+
+// LTTyped is like LT but the type of value is checked at compile time.
+func (c columnBase[T]) LTTyped(value T) OpOption {
+	return NewOpQueryOption(c.ColumnName, OpLt, value)
+}
+
+// GTETyped is like GTE but the type of value is checked at compile time.
+func (c columnBase[T]) GTETyped(value T) OpOption {
+	return NewOpQueryOption(c.ColumnName, OpGte, value)
+}
+
+// LTETyped is like LTE but the type of value is checked at compile time.
+func (c columnBase[T]) LTETyped(value T) OpOption {
+	return NewOpQueryOption(c.ColumnName, OpLte, value)
+}
+
 func (c columnBase[T]) In(values []T) RangeQueryOption {
 	return NewRangeQueryOption(c.ColumnName, values, false)
 }
@@ -455,14 +862,113 @@ func (c columnBase[T]) NotIn(values []T) RangeQueryOption {
 	return NewRangeQueryOption(c.ColumnName, values, true)
 }
 
+// Any compares the column with values using op and matches if any of the comparisons holds, e.g. `column > ANY(values)`.
+func (c columnBase[T]) Any(op QueryOp, values []T) OpOption {
+	return NewQuantifiedQueryOption(c.ColumnName, op, QuantifierAny, values)
+}
+
+// All compares the column with values using op and matches if all of the comparisons hold, e.g. `column > ALL(values)`.
+func (c columnBase[T]) All(op QueryOp, values []T) OpOption {
+	return NewQuantifiedQueryOption(c.ColumnName, op, QuantifierAll, values)
+}
+
+// EqAny is like In but compiles to `column = ANY(values)` on Postgres, which passes all values as a single array.
+func (c columnBase[T]) EqAny(values []T) OpOption {
+	return c.Any(OpEq, values)
+}
+
+// GtAny matches if the column is greater than any of values.
+func (c columnBase[T]) GtAny(values []T) OpOption {
+	return c.Any(OpGt, values)
+}
+
+// GtAll matches if the column is greater than all of values.
+func (c columnBase[T]) GtAll(values []T) OpOption {
+	return c.All(OpGt, values)
+}
+
+// LtAny matches if the column is less than any of values.
+func (c columnBase[T]) LtAny(values []T) OpOption {
+	return c.Any(OpLt, values)
+}
+
+// LtAll matches if the column is less than all of values.
+func (c columnBase[T]) LtAll(values []T) OpOption {
+	return c.All(OpLt, values)
+}
+
+// FuzzyIn matches the column with the patterns using LIKE, it panics if the column is not of a string type.
 func (c columnBase[T]) FuzzyIn(values []T) FuzzyQueryOption {
 	return NewFuzzyQueryOption(c.ColumnName, values)
 }
 
+// FuzzyInCaseSensitive is like FuzzyIn but matches the patterns case-sensitively, see WithCaseSensitiveCollation.
+func (c columnBase[T]) FuzzyInCaseSensitive(values []T) FuzzyQueryOption {
+	return NewCaseSensitiveFuzzyQueryOption(c.ColumnName, values)
+}
+
+// SortByValues sorts data by the positions of the column values in values, for example to keep the order of
+// the values of an In filter.
+func (c columnBase[T]) SortByValues(values []T) ValuesSortOption {
+	return NewValuesSortOption(c.ColumnName, values)
+}
+
+// Update updates the column with value, value can also be a ColumnRef, a Subquery or a clause.Expr which is rendered as it is.
+// A nil value sets the column to NULL like UpdateNull.
 func (c columnBase[T]) Update(value any) UpdateOption {
+	switch v := value.(type) {
+	case nil:
+		return c.UpdateNull()
+	case ColumnRef, clause.Expr, Subquery:
+		return NewUpdateOption(c.ColumnName, v)
+	}
 	return NewUpdateOption(c.ColumnName, lo.Must(c.convertFrom(value)))
 }
 
+// FlagUpdate is the value of an UpdateOption which sets or clears the bits of Mask in an integer column,
+// it is translated to `column | ?` or `column & ~?`.
+type FlagUpdate struct {
+	Mask  uint64
+	Clear bool
+}
+
+// HasFlag matches rows in which all bits of mask are set in the column, it panics if the column is not an integer column.
+func (c columnBase[T]) HasFlag(mask uint64) OpOption {
+	c.mustIntegerColumn()
+	return NewOpQueryOption(c.ColumnName, OpHasFlag, mask)
+}
+
+// SetFlag sets the bits of mask in the column and keeps the other bits, it panics if the column is not an integer column.
+func (c columnBase[T]) SetFlag(mask uint64) UpdateOption {
+	c.mustIntegerColumn()
+	return NewUpdateOption(c.ColumnName, FlagUpdate{Mask: mask})
+}
+
+// ClearFlag clears the bits of mask in the column and keeps the other bits, it panics if the column is not an integer column.
+func (c columnBase[T]) ClearFlag(mask uint64) UpdateOption {
+	c.mustIntegerColumn()
+	return NewUpdateOption(c.ColumnName, FlagUpdate{Mask: mask, Clear: true})
+}
+
+// mustIntegerColumn panics if the column does not hold integers, so that flag operations are rejected where they are built.
+func (c columnBase[T]) mustIntegerColumn() {
+	rt := c.reflectType()
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	switch rt.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		panic(fmt.Sprintf("flags are only supported by integer columns, the column %s is of type %s", c.ColumnName, rt))
+	}
+}
+
+// UpdateNull sets the column to NULL, the value is neither converted nor serialized.
+func (c columnBase[T]) UpdateNull() UpdateOption {
+	return NewUpdateOption[any](c.ColumnName, nil)
+}
+
 /*
 PtrColumn is used when declaring models with pointer fields, for example:
 
@@ -491,6 +997,42 @@ func NewPtrColumn[T any](v T) PtrColumn[T] {
 	}
 }
 
+// EqNullSafe is like EQ but NULL matches NULL, the column is compared with NULL if value is nil or a nil *T.
+// value can also be a column reference like the one of other columns.
+func (c PtrColumn[T]) EqNullSafe(value any) OpOption {
+	if ptr, ok := value.(*T); ok {
+		if ptr == nil {
+			return NewOpQueryOption[any](c.ColumnName, OpEqNullSafe, nil)
+		}
+		return NewOpQueryOption(c.ColumnName, OpEqNullSafe, *ptr)
+	}
+	return c.columnBase.EqNullSafe(value)
+}
+
+func (c PtrColumn[T]) EQTyped(value T) OpOption {
+	return NewOpQueryOption(c.ColumnName, OpEq, value)
+}
+
+func (c PtrColumn[T]) NETyped(value T) OpOption {
+	return NewOpQueryOption(c.ColumnName, OpNe, value)
+}
+
+func (c PtrColumn[T]) GTTyped(value T) OpOption {
+	return NewOpQueryOption(c.ColumnName, OpGt, value)
+}
+
+func (c PtrColumn[T]) LTTyped(value T) OpOption {
+	return NewOpQueryOption(c.ColumnName, OpLt, value)
+}
+
+func (c PtrColumn[T]) GTETyped(value T) OpOption {
+	return NewOpQueryOption(c.ColumnName, OpGte, value)
+}
+
+func (c PtrColumn[T]) LTETyped(value T) OpOption {
+	return NewOpQueryOption(c.ColumnName, OpLte, value)
+}
+
 func (c PtrColumn[T]) In(values []T) RangeQueryOption {
 	return NewRangeQueryOption(c.ColumnName, values, false)
 }
@@ -499,10 +1041,57 @@ func (c PtrColumn[T]) NotIn(values []T) RangeQueryOption {
 	return NewRangeQueryOption(c.ColumnName, values, true)
 }
 
+// Any compares the column with values using op and matches if any of the comparisons holds, e.g. `column > ANY(values)`.
+func (c PtrColumn[T]) Any(op QueryOp, values []T) OpOption {
+	return NewQuantifiedQueryOption(c.ColumnName, op, QuantifierAny, values)
+}
+
+// All compares the column with values using op and matches if all of the comparisons hold, e.g. `column > ALL(values)`.
+func (c PtrColumn[T]) All(op QueryOp, values []T) OpOption {
+	return NewQuantifiedQueryOption(c.ColumnName, op, QuantifierAll, values)
+}
+
+// EqAny is like In but compiles to `column = ANY(values)` on Postgres, which passes all values as a single array.
+func (c PtrColumn[T]) EqAny(values []T) OpOption {
+	return c.Any(OpEq, values)
+}
+
+// GtAny matches if the column is greater than any of values.
+func (c PtrColumn[T]) GtAny(values []T) OpOption {
+	return c.Any(OpGt, values)
+}
+
+// GtAll matches if the column is greater than all of values.
+func (c PtrColumn[T]) GtAll(values []T) OpOption {
+	return c.All(OpGt, values)
+}
+
+// LtAny matches if the column is less than any of values.
+func (c PtrColumn[T]) LtAny(values []T) OpOption {
+	return c.Any(OpLt, values)
+}
+
+// LtAll matches if the column is less than all of values.
+func (c PtrColumn[T]) LtAll(values []T) OpOption {
+	return c.All(OpLt, values)
+}
+
+// FuzzyIn matches the column with the patterns using LIKE, it panics if the column is not of a string type.
 func (c PtrColumn[T]) FuzzyIn(values []T) FuzzyQueryOption {
 	return NewFuzzyQueryOption(c.ColumnName, values)
 }
 
+// FuzzyInCaseSensitive is like FuzzyIn but matches the patterns case-sensitively, see WithCaseSensitiveCollation.
+func (c PtrColumn[T]) FuzzyInCaseSensitive(values []T) FuzzyQueryOption {
+	return NewCaseSensitiveFuzzyQueryOption(c.ColumnName, values)
+}
+
+// SortByValues sorts data by the positions of the column values in values, for example to keep the order of
+// the values of an In filter.
+func (c PtrColumn[T]) SortByValues(values []T) ValuesSortOption {
+	return NewValuesSortOption(c.ColumnName, values)
+}
+
 // Column represents a column of a table.
 type Column[T any] struct {
 	columnBase[T]
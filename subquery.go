@@ -0,0 +1,53 @@
+package sqldb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm/clause"
+)
+
+// Subquery is a query which selects a single value, it can be used as the value of UpdateOptions, e.g.
+//
+//	cols.Weight.Update(sqldb.NewSubquery(Relations.Query(rc.UserName.EQ(sqldb.Ref(cols.Name))), sqldb.AggregateMax, rc.Age))
+//
+// which is rendered as `weight = (SELECT MAX(relations.age) FROM relations WHERE relations.user_name = users.user_name)`.
+type Subquery interface {
+	expr(ctx context.Context) (clause.Expr, error)
+}
+
+type subquery[T any] struct {
+	executor Executor[T]
+	fn       AggregateFunc
+	col      ColumnNameGetter
+}
+
+// NewSubquery returns a subquery which selects fn over col of the entities matched by e, col is selected as it is
+// if fn is empty. Columns in the filters of e are qualified with table names so that they can refer to the outer query.
+func NewSubquery[T any](e Executor[T], fn AggregateFunc, col ColumnNameGetter) Subquery {
+	return subquery[T]{executor: e, fn: fn, col: col}
+}
+
+func (s subquery[T]) expr(ctx context.Context) (clause.Expr, error) {
+	e, ok := s.executor.(executor[T])
+	if !ok {
+		return clause.Expr{}, errors.New("subqueries are only supported by models created by NewModel or joins")
+	}
+	db := e.DB(ctx)
+	if !e.joined {
+		db = db.Model(new(T))
+		if e.config.tableAlias != "" {
+			db = db.Table(e.tableExpr())
+		}
+	}
+	db, err := e.newApplyHelper(db, true).applyFilterOptions(ctx, e.filters()).Result().Get()
+	if err != nil {
+		return clause.Expr{}, err
+	}
+	selected := getColumnName(true, s.col)
+	if s.fn != "" {
+		selected = fmt.Sprintf("%s(%s)", s.fn, selected)
+	}
+	return clause.Expr{SQL: "(?)", Vars: []any{db.Select(selected)}}, nil
+}
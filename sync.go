@@ -0,0 +1,44 @@
+package sqldb
+
+import "context"
+
+// SyncModel wraps a Model with *Sync methods which use context.Background(), it is meant for scripts and
+// migrations where threading contexts is only noise.
+type SyncModel[T any] struct {
+	Model[T]
+}
+
+// NewSyncModel returns a SyncModel which wraps m.
+func NewSyncModel[T any](m Model[T]) SyncModel[T] {
+	return SyncModel[T]{Model: m}
+}
+
+// CreateSync creates a new entity.
+func (m SyncModel[T]) CreateSync(entity *T) error {
+	return m.Create(context.Background(), entity)
+}
+
+// GetSync returns the first entity matching the filters.
+func (m SyncModel[T]) GetSync(filters ...FilterOption) (T, error) {
+	return m.Query(filters...).Get(context.Background())
+}
+
+// ListSync lists the entities matching the filters and returns the total number of them.
+func (m SyncModel[T]) ListSync(opts ListOptions, filters ...FilterOption) ([]T, uint64, error) {
+	return m.Query(filters...).List(context.Background(), opts)
+}
+
+// FindSync lists the entities matching the filters without counting them.
+func (m SyncModel[T]) FindSync(opts ListOptions, filters ...FilterOption) ([]T, error) {
+	return m.Query(filters...).Find(context.Background(), opts)
+}
+
+// UpdateSync updates the entities matching the filters and returns the number of updated rows.
+func (m SyncModel[T]) UpdateSync(filters []FilterOption, opts ...UpdateOption) (uint64, error) {
+	return m.Query(filters...).Update(context.Background(), opts...)
+}
+
+// DeleteSync deletes the entities matching the filters.
+func (m SyncModel[T]) DeleteSync(filters ...FilterOption) error {
+	return m.Query(filters...).Delete(context.Background())
+}
@@ -2,12 +2,20 @@ package sqldb
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
+
+	gormschema "gorm.io/gorm/schema"
 )
 
+var columnNameSetterType = reflect.TypeOf((*columnNameSetter)(nil)).Elem()
+
 type fieldIterator func(fieldAddr reflect.Value, path []reflect.StructField) (bool, error)
 
-func iterateFields(obj any, fi fieldIterator, path ...reflect.StructField) error {
+// iterateFields calls fi with the address of each exported field of obj, fields of the structs are walked if fi
+// returns true. Nil embedded pointers to structs are allocated if alloc is true, e.g. for the column template of a
+// model, otherwise obj is left untouched and fi is called with invalid addresses for the fields behind them.
+func iterateFields(obj any, alloc bool, fi fieldIterator, path ...reflect.StructField) error {
 	rv := reflect.ValueOf(obj)
 	if rv.Kind() != reflect.Pointer || rv.IsNil() {
 		return errors.New("object provided is not a ptr or it's nil")
@@ -19,6 +27,10 @@ func iterateFields(obj any, fi fieldIterator, path ...reflect.StructField) error
 	for i := 0; i < e.NumField(); i++ {
 		typeField := e.Type().Field(i)
 		if !typeField.IsExported() {
+			// an unexported column can not be set or read, it is always a mistake.
+			if reflect.PointerTo(typeField.Type).Implements(columnNameSetterType) {
+				return fmt.Errorf("column field %s of %s is unexported", typeField.Name, e.Type())
+			}
 			continue
 		}
 		fieldAddr := e.Field(i).Addr()
@@ -27,8 +39,27 @@ func iterateFields(obj any, fi fieldIterator, path ...reflect.StructField) error
 		if err != nil {
 			return err
 		}
-		if dive {
-			if err := iterateFields(fieldAddr.Interface(), fi, p...); err != nil {
+		// only structs and embedded pointers to structs are walked. Fields of other kinds, such as slices or maps
+		// of structs holding columns, are stored as whole values by gorm, so the columns inside their elements
+		// are not columns of the model and are left untouched.
+		if dive && (typeField.Type.Kind() == reflect.Struct || isEmbeddedPtr(typeField)) {
+			next := fi
+			if isEmbeddedPtr(typeField) {
+				switch {
+				case !fieldAddr.Elem().IsNil():
+					fieldAddr = fieldAddr.Elem()
+				case alloc:
+					fieldAddr.Elem().Set(reflect.New(typeField.Type.Elem()))
+					fieldAddr = fieldAddr.Elem()
+				default:
+					// the fields of a detached struct are walked for their paths only.
+					fieldAddr = reflect.New(typeField.Type.Elem())
+					next = func(_ reflect.Value, path []reflect.StructField) (bool, error) {
+						return fi(reflect.Value{}, path)
+					}
+				}
+			}
+			if err := iterateFields(fieldAddr.Interface(), alloc, next, p...); err != nil {
 				return err
 			}
 		}
@@ -36,6 +67,45 @@ func iterateFields(obj any, fi fieldIterator, path ...reflect.StructField) error
 	return nil
 }
 
+// isEmbeddedPtr reports whether sf is an embedded pointer to a struct.
+func isEmbeddedPtr(sf reflect.StructField) bool {
+	if sf.Type.Kind() != reflect.Pointer || sf.Type.Elem().Kind() != reflect.Struct {
+		return false
+	}
+	_, embedded := gormschema.ParseTagSetting(sf.Tag.Get("gorm"), ";")["EMBEDDED"]
+	return sf.Anonymous || embedded
+}
+
+// columnValue returns the value of the column field at fieldAddr, which is nil for an invalid address of a column
+// behind a nil embedded pointer.
+func columnValue(fieldAddr reflect.Value) any {
+	if !fieldAddr.IsValid() {
+		return nil
+	}
+	return fieldAddr.Elem().FieldByName("V").Interface()
+}
+
+// setColumnValue sets the value of the column field at fieldAddr to v, which is converted to the type of the column.
+// A nil v sets the zero value.
+func setColumnValue(fieldAddr reflect.Value, v any) error {
+	field := fieldAddr.Elem().FieldByName("V")
+	if v == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if !rv.CanConvert(field.Type()) && field.Kind() == reflect.Pointer && rv.CanConvert(field.Type().Elem()) {
+		ptr := reflect.New(field.Type().Elem())
+		ptr.Elem().Set(rv.Convert(field.Type().Elem()))
+		rv = ptr
+	}
+	if !rv.CanConvert(field.Type()) {
+		return fmt.Errorf("unable to convert value of type %s to the column type %s", rv.Type(), field.Type())
+	}
+	field.Set(rv.Convert(field.Type()))
+	return nil
+}
+
 func MapErr[T any, R any](collection []T, iteratee func(T, int) (R, error)) ([]R, error) {
 	result := make([]R, len(collection))
 